@@ -0,0 +1,10 @@
+// Package assets holds the images, sounds, videos and data files of the
+// game, built into the program. manifest.json names the ones the game uses.
+package assets
+
+import "embed"
+
+// FS holds every asset, at the same paths as in this directory.
+//
+//go:embed *.json *.png *.mp3 *.mpg enemies i18n levels
+var FS embed.FS
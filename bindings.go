@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"ghost/input"
+)
+
+// binding is what triggers an action: any of its keys, or any of its buttons
+// on any gamepad with the standard layout.
+type binding struct {
+	Keys    []ebiten.Key    `json:"keys"`
+	Gamepad []gamepadButton `json:"gamepad"`
+}
+
+// bindings map every action to what triggers it.
+type bindings map[input.Button]*binding
+
+// gamepadButton is a button of the standard gamepad layout, stored and shown
+// by the name of its Xbox counterpart.
+type gamepadButton ebiten.StandardGamepadButton
+
+var gamepadButtonNames = map[gamepadButton]string{
+	gamepadButton(ebiten.StandardGamepadButtonRightBottom):      "A",
+	gamepadButton(ebiten.StandardGamepadButtonRightRight):       "B",
+	gamepadButton(ebiten.StandardGamepadButtonRightLeft):        "X",
+	gamepadButton(ebiten.StandardGamepadButtonRightTop):         "Y",
+	gamepadButton(ebiten.StandardGamepadButtonFrontTopLeft):     "LB",
+	gamepadButton(ebiten.StandardGamepadButtonFrontTopRight):    "RB",
+	gamepadButton(ebiten.StandardGamepadButtonFrontBottomLeft):  "LT",
+	gamepadButton(ebiten.StandardGamepadButtonFrontBottomRight): "RT",
+	gamepadButton(ebiten.StandardGamepadButtonCenterLeft):       "Back",
+	gamepadButton(ebiten.StandardGamepadButtonCenterRight):      "Start",
+	gamepadButton(ebiten.StandardGamepadButtonCenterCenter):     "Home",
+	gamepadButton(ebiten.StandardGamepadButtonLeftStick):        "LS",
+	gamepadButton(ebiten.StandardGamepadButtonRightStick):       "RS",
+	gamepadButton(ebiten.StandardGamepadButtonLeftTop):          "DpadUp",
+	gamepadButton(ebiten.StandardGamepadButtonLeftBottom):       "DpadDown",
+	gamepadButton(ebiten.StandardGamepadButtonLeftLeft):         "DpadLeft",
+	gamepadButton(ebiten.StandardGamepadButtonLeftRight):        "DpadRight",
+}
+
+func (b gamepadButton) String() string {
+	if name, ok := gamepadButtonNames[b]; ok {
+		return name
+	}
+	return fmt.Sprintf("Button%d", int(b))
+}
+
+func (b gamepadButton) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *gamepadButton) UnmarshalText(text []byte) error {
+	for button, name := range gamepadButtonNames {
+		if name == string(text) {
+			*b = button
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown gamepad button %q", text)
+}
+
+// defaultBindings returns the bindings for the arrow keys, WASD and
+// standard gamepads.
+func defaultBindings() bindings {
+	pad := func(b ebiten.StandardGamepadButton) []gamepadButton {
+		return []gamepadButton{gamepadButton(b)}
+	}
+	return bindings{
+		input.Left:    {Keys: []ebiten.Key{ebiten.KeyArrowLeft, ebiten.KeyA}, Gamepad: pad(ebiten.StandardGamepadButtonLeftLeft)},
+		input.Right:   {Keys: []ebiten.Key{ebiten.KeyArrowRight, ebiten.KeyD}, Gamepad: pad(ebiten.StandardGamepadButtonLeftRight)},
+		input.Up:      {Keys: []ebiten.Key{ebiten.KeyArrowUp, ebiten.KeyW}, Gamepad: pad(ebiten.StandardGamepadButtonLeftTop)},
+		input.Down:    {Keys: []ebiten.Key{ebiten.KeyArrowDown, ebiten.KeyS}, Gamepad: pad(ebiten.StandardGamepadButtonLeftBottom)},
+		input.Fire:    {Keys: []ebiten.Key{ebiten.KeySpace}, Gamepad: pad(ebiten.StandardGamepadButtonRightBottom)},
+		input.Bomb:    {Keys: []ebiten.Key{ebiten.KeyB}, Gamepad: pad(ebiten.StandardGamepadButtonRightLeft)},
+		input.Pause:   {Keys: []ebiten.Key{ebiten.KeyP}, Gamepad: pad(ebiten.StandardGamepadButtonCenterRight)},
+		input.Confirm: {Keys: []ebiten.Key{ebiten.KeyEnter}, Gamepad: pad(ebiten.StandardGamepadButtonRightBottom)},
+		input.Back:    {Keys: []ebiten.Key{ebiten.KeyEscape}, Gamepad: pad(ebiten.StandardGamepadButtonRightRight)},
+
+		input.Quicksave: {Keys: []ebiten.Key{ebiten.KeyF5}},
+		input.Quickload: {Keys: []ebiten.Key{ebiten.KeyF9}},
+	}
+}
+
+func (b bindings) MarshalJSON() ([]byte, error) {
+	saved := map[string]*binding{}
+	for action, bd := range b {
+		saved[action.String()] = bd
+	}
+	return json.Marshal(saved)
+}
+
+// UnmarshalJSON reads saved bindings over the defaults, so actions missing
+// from them keep their default bindings.
+func (b *bindings) UnmarshalJSON(data []byte) error {
+	var saved map[string]*binding
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	*b = defaultBindings()
+	for name, bd := range saved {
+		action, ok := input.ParseAction(name)
+		if !ok {
+			return fmt.Errorf("unknown action %q", name)
+		}
+		if bd == nil {
+			bd = &binding{} // Unbound
+		}
+		(*b)[action] = bd
+	}
+	return nil
+}
+
+// describe lists what triggers an action, keys first.
+func (bd *binding) describe() string {
+	var names []string
+	for _, k := range bd.Keys {
+		names = append(names, k.String())
+	}
+	for _, b := range bd.Gamepad {
+		names = append(names, b.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// keyName names the first key or gamepad button bound to an action, for the
+// hints that tell the player what to press.
+func (g *Game) keyName(action input.Button) string {
+	bd := g.settings.Bindings[action]
+	switch {
+	case bd == nil:
+	case len(bd.Keys) > 0:
+		return bd.Keys[0].String()
+	case len(bd.Gamepad) > 0:
+		return bd.Gamepad[0].String()
+	}
+	return "-"
+}
+
+func (bd *binding) pressed(pads []ebiten.GamepadID) bool {
+	for _, k := range bd.Keys {
+		if ebiten.IsKeyPressed(k) {
+			return true
+		}
+	}
+	for _, id := range pads {
+		for _, b := range bd.Gamepad {
+			if ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButton(b)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// menuKeys are the menu shortcuts, which can't be rebound.
+var menuKeys = []struct {
+	key    ebiten.Key
+	button input.Button
+}{
+	{ebiten.Key1, input.Key1},
+	{ebiten.Key2, input.Key2},
+}
+
+// stickDeadZone is how far the analog stick has to be pushed to move the
+// player, so a worn stick doesn't make it drift.
+const stickDeadZone = 0.2
+
+// readInput captures the current keyboard, gamepad and mouse state.
+func (g *Game) readInput() input.State {
+	var s input.State
+	g.gamepads = ebiten.AppendGamepadIDs(g.gamepads[:0])
+	for _, action := range input.Actions {
+		if bd, ok := g.settings.Bindings[action]; ok && bd.pressed(g.gamepads) {
+			s.Buttons |= action
+		}
+	}
+	for _, mk := range menuKeys {
+		if ebiten.IsKeyPressed(mk.key) {
+			s.Buttons |= mk.button
+		}
+	}
+	// Take the left stick of the first gamepad that pushes it
+	for _, id := range g.gamepads {
+		x := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		y := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+		if math.Hypot(x, y) >= stickDeadZone {
+			s.MoveX, s.MoveY = stickAxis(x), stickAxis(y)
+			break
+		}
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		s.Click = true
+		s.CursorX, s.CursorY = ebiten.CursorPosition()
+	}
+	return s
+}
+
+// stickAxis quantizes an axis value so it can be recorded.
+func stickAxis(v float64) int8 {
+	return int8(math.Round(math.Max(-1, math.Min(1, v)) * 127))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// bombFlashDuration is how many frames the bomb flash lasts.
+const bombFlashDuration = 30
+
+// bombSound synthesizes the rumble of a bomb: low-passed noise fading out
+// over 0.8 seconds, as 16-bit stereo samples.
+func bombSound(context *audio.Context) *audio.Player {
+	rate := context.SampleRate()
+	n := rate * 8 / 10
+	noise := rand.New(rand.NewSource(1)) // Same rumble every time, and the game's rng is left alone
+	buf := make([]byte, n*4)
+	var v float64
+	for i := 0; i < n; i++ {
+		// Exponential moving average keeps the low frequencies
+		v += 0.05 * (noise.Float64()*2 - 1 - v)
+		fade := math.Pow(1-float64(i)/float64(n), 2)
+		s := int16(clampSample(v * 4 * fade * math.MaxInt16))
+		for c := 0; c < 2; c++ {
+			buf[4*i+2*c] = byte(s)
+			buf[4*i+2*c+1] = byte(s >> 8)
+		}
+	}
+	return context.NewPlayerFromBytes(buf)
+}
+
+func clampSample(s float64) float64 {
+	return math.Max(math.Min(s, math.MaxInt16), math.MinInt16)
+}
+
+// playBomb starts the bomb flash and sound.
+func (g *Game) playBomb() {
+	g.bombFlash = bombFlashDuration
+	g.bombX, g.bombY = g.world.Player.X+17, g.world.Player.Y+17
+	g.playRumble()
+}
+
+// playRumble plays the sound of bombs and explosions.
+func (g *Game) playRumble() {
+	if g.bombPlayer == nil {
+		g.bombPlayer = bombSound(g.audioContext)
+		g.applyVolume()
+	}
+	g.bombPlayer.Rewind()
+	g.bombPlayer.Play()
+}
+
+// drawBomb draws a shock wave spreading from where the bomb was dropped
+// over a fading white flash.
+func (g *Game) drawBomb(screen *ebiten.Image) {
+	if g.bombFlash == 0 {
+		return
+	}
+	left := float32(g.bombFlash) / bombFlashDuration
+	if !g.settings.ReduceFlashes {
+		flash := color.NRGBA{0xff, 0xff, 0xff, uint8(0x90 * left)}
+		vector.DrawFilledRect(screen, 0, 0, float32(screenWidth), float32(screenHeight), flash, false)
+	}
+	radius := (1 - left) * float32(screenWidth)
+	wave := color.NRGBA{0xff, 0xd0, 0x80, uint8(0xff * left)}
+	vector.StrokeCircle(screen, float32(g.bombX), float32(g.bombY), radius, 6, wave, true)
+}
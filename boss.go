@@ -0,0 +1,56 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"ghost/sim"
+)
+
+var (
+	laserColor         = color.RGBA{0xff, 0x40, 0x40, 0xc0}
+	laserWarningColor  = color.RGBA{0xff, 0x40, 0x40, 0x80}
+	healthBarColor     = color.RGBA{0xd0, 0x20, 0x20, 0xff}
+	healthBarBackColor = color.RGBA{0x40, 0x40, 0x40, 0xc0}
+)
+
+// drawBoss draws the boss with its laser, blinking while it telegraphs an
+// attack.
+func (g *Game) drawBoss(screen *ebiten.Image) {
+	b := &g.world.Boss
+	telegraphing := b.Stage == sim.BossTelegraph
+
+	if b.CurrentAttack().Pattern == sim.AttackLaser && (telegraphing || b.Laser) {
+		left, right := b.LaserBounds()
+		top := float32(b.Y + 32)
+		if b.Laser {
+			vector.DrawFilledRect(screen, float32(left), top, float32(right-left), screenHeight-top, laserColor, false)
+		} else {
+			center := float32(left+right) / 2
+			vector.StrokeLine(screen, center, top, center, screenHeight, 1, laserWarningColor, false)
+		}
+	}
+
+	var cm ebiten.ColorM
+	if telegraphing && b.StageTicks/8%2 == 0 {
+		cm.Scale(1, 0.3, 0.3, 1)
+	}
+	g.drawFrame(screen, g.bossFrame(), b.X, b.Y, cm)
+}
+
+// drawBossHealthBar draws the name and remaining health of the boss across
+// the top of the screen.
+func (g *Game) drawBossHealthBar(screen *ebiten.Image) {
+	const (
+		x      = 120
+		y      = 24
+		width  = screenWidth - 2*x
+		height = 6
+	)
+	b := &g.world.Boss
+	drawText(screen, g.lang.T(b.Config.Name), x, y-18)
+	vector.DrawFilledRect(screen, x, y, width, height, healthBarBackColor, false)
+	vector.DrawFilledRect(screen, x, y, float32(width*b.HealthFraction()), height, healthBarColor, false)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+
+	"ghost/sim"
+)
+
+// config holds the options the game was started with.
+type config struct {
+	// seed for the random source of every run; 0 takes the seed of the
+	// settings, and picks a new seed per run if that is 0 too.
+	seed int64
+	// record is the file the input of the session is recorded to.
+	record string
+	// replay is a recorded file to play back instead of live input.
+	replay string
+	// load is a snapshot file to continue a run from.
+	load string
+	// assets is a directory whose files replace the built-in assets.
+	assets string
+	// manualFire turns auto-fire off so weapons only fire while the fire
+	// button is held.
+	manualFire bool
+	// invulnerability is how many seconds the player can't be hit for
+	// after respawning.
+	invulnerability float64
+}
+
+func parseConfig() config {
+	var cfg config
+	flag.Int64Var(&cfg.seed, "seed", 0, "seed for reproducible runs (0 picks a random seed)")
+	flag.StringVar(&cfg.record, "record", "", "record the session's input to this replay file")
+	flag.StringVar(&cfg.replay, "replay", "", "play back a replay file instead of live input")
+	flag.StringVar(&cfg.load, "load", "", "continue the run saved in this snapshot file, such as a quicksave")
+	flag.StringVar(&cfg.assets, "assets", "", "directory whose files replace the built-in assets, for mods")
+	flag.BoolVar(&cfg.manualFire, "manual-fire", false, "only fire while the fire button (Space) is held")
+	flag.Float64Var(&cfg.invulnerability, "invulnerability", sim.DefaultInvulnerability, "seconds the player can't be hit for after respawning")
+	flag.Parse()
+	return cfg
+}
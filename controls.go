@@ -0,0 +1,99 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"ghost/input"
+	"ghost/ui"
+)
+
+// controlsScene lets the player rebind the actions. Picking an action waits
+// for the next key or gamepad button, which replaces the keys or the gamepad
+// buttons of the action respectively.
+type controlsScene struct {
+	baseScene
+	root    *ui.Panel
+	buttons map[input.Button]*ui.Button
+	hint    *ui.Label
+	waiting input.Button // Action waiting for a key or button, 0 if none
+
+	keys    []ebiten.Key
+	pressed []ebiten.StandardGamepadButton
+}
+
+func (s *controlsScene) Enter(g *Game) {
+	s.buttons = map[input.Button]*ui.Button{}
+	s.hint = &ui.Label{Base: ui.Base{Anchor: ui.Top}}
+	s.root = &ui.Panel{Base: ui.Base{Anchor: ui.Center}, Vertical: true, Spacing: 4}
+	s.root.Add(&ui.Label{Base: ui.Base{Anchor: ui.Top}, Text: g.lang.T("controls.title")})
+	for _, action := range input.Actions {
+		action := action
+		// Capturing reads keys and buttons the replay doesn't record
+		b := &ui.Button{Base: ui.Base{Anchor: ui.Top, W: 420, Disabled: g.replaying()}, OnClick: func() { s.waiting = action }}
+		s.buttons[action] = b
+		s.root.Add(b)
+	}
+	s.root.Add(
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200, Disabled: g.replaying()}, Text: g.lang.T("controls.reset"), OnClick: func() {
+			g.settings.Bindings = defaultBindings()
+			g.saveSettings()
+		}},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200}, Text: g.lang.T("menu.back"), OnClick: g.popScene},
+		s.hint,
+	)
+	s.refresh(g)
+}
+
+func (s *controlsScene) Update(g *Game) error {
+	if s.waiting != 0 {
+		s.capture(g)
+	} else if g.justPressed(input.Back) {
+		g.popScene()
+		return nil
+	} else {
+		g.updateUI(s.root)
+	}
+	s.refresh(g)
+	return nil
+}
+
+// refresh shows the current bindings and what the player is asked to do.
+func (s *controlsScene) refresh(g *Game) {
+	for action, b := range s.buttons {
+		b.Text = g.lang.T("controls.action", "action", g.lang.T("action."+action.String()), "binding", g.settings.Bindings[action].describe())
+	}
+	s.hint.Text = g.lang.T("controls.hint")
+	if g.replaying() {
+		s.hint.Text = g.lang.T("controls.replay")
+	} else if s.waiting != 0 {
+		s.hint.Text = g.lang.T("controls.waiting", "action", g.lang.T("action."+s.waiting.String()))
+	}
+}
+
+// capture binds the first key or gamepad button pressed to the action
+// waiting for one. Escape cancels.
+func (s *controlsScene) capture(g *Game) {
+	s.keys = inpututil.AppendJustPressedKeys(s.keys[:0])
+	for _, k := range s.keys {
+		if k != ebiten.KeyEscape {
+			g.settings.Bindings[s.waiting].Keys = []ebiten.Key{k}
+			g.saveSettings()
+		}
+		s.waiting = 0
+		return
+	}
+	for _, id := range g.gamepads {
+		s.pressed = inpututil.AppendJustPressedStandardGamepadButtons(id, s.pressed[:0])
+		if len(s.pressed) > 0 {
+			g.settings.Bindings[s.waiting].Gamepad = []gamepadButton{gamepadButton(s.pressed[0])}
+			g.saveSettings()
+			s.waiting = 0
+			return
+		}
+	}
+}
+
+func (s *controlsScene) Draw(g *Game, screen *ebiten.Image) {
+	g.drawUI(s.root, screen)
+}
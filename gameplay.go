@@ -0,0 +1,180 @@
+package main
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"ghost/sim"
+)
+
+// outlineColor rings enemy bullets when they are outlined.
+var outlineColor = color.NRGBA{0xff, 0xff, 0x00, 0xff}
+
+// gameplayScene runs the world. In Story mode it also checks the goal of the
+// level.
+type gameplayScene struct {
+	baseScene
+}
+
+func (s *gameplayScene) Update(g *Game) error {
+	// The pause and home buttons and the pause action leave the gameplay
+	g.hud.update(g)
+	if g.topScene() != Scene(s) {
+		return nil
+	}
+
+	fired := false
+	for _, event := range g.world.Step(g.simInput(), 1/float64(ebiten.TPS())) {
+		g.particleEvent(event)
+		switch event.Kind {
+		case sim.EventPlayerFire:
+			fired = true
+		case sim.EventEnemyHit, sim.EventBossHit:
+			// Play the shooting sound effect
+			if err := g.shootingPlayer.Rewind(); err != nil {
+				log.Fatal(err)
+			}
+			g.shootingPlayer.Play()
+		case sim.EventEnemyDestroyed:
+			g.explode(event.X, event.Y)
+		case sim.EventBossDefeated:
+			g.explode(event.X, event.Y)
+			g.completeLevel()
+		case sim.EventBomb:
+			g.playBomb()
+		case sim.EventPlayerHit:
+			g.playRumble()
+		}
+	}
+	g.animate(fired)
+	g.updateParticles()
+	if g.bombFlash > 0 {
+		g.bombFlash--
+	}
+
+	// Check if the audio player has finished playing
+	if g.musicPlayer.IsPlaying() == false {
+		g.musicPlayer.Rewind()
+		g.musicPlayer.Play()
+	}
+
+	// The level may have been completed meanwhile
+	if g.topScene() != Scene(s) {
+		return nil
+	}
+
+	// Check for game over condition
+	if g.world.GameOver() {
+		g.pushScene(&gameOverScene{})
+		return nil
+	}
+	if g.gameMode == Story {
+		g.checkGoal()
+	}
+	return nil
+}
+
+func (s *gameplayScene) Draw(g *Game, screen *ebiten.Image) {
+	if g.gameMode == Competition {
+		// Draw background image for competition mode
+		effectiveY := int(g.world.BgOffsetY) % backgroundImage.Bounds().Dy()
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(0, float64(effectiveY))
+		screen.DrawImage(backgroundImage, op)
+
+		op = &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(0, float64(effectiveY-backgroundImage.Bounds().Dy()))
+		screen.DrawImage(backgroundImage, op)
+	} else if g.gameMode == Story {
+		// Draw background image for story mode
+		currentChapterBackground := g.background(g.currentLevel().Background)
+
+		// Calculate the effective position of the background image by taking the modulo
+		effectiveY := int(g.world.BgOffsetY) % currentChapterBackground.Bounds().Dy()
+
+		// Draw the background image at the current effective position
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(0, float64(effectiveY))
+		screen.DrawImage(currentChapterBackground, op)
+
+		// Draw the background image again just below the first one to create the illusion of an infinite loop
+		op = &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(0, float64(effectiveY-currentChapterBackground.Bounds().Dy()))
+		screen.DrawImage(currentChapterBackground, op)
+	}
+
+	// Draw player
+	g.drawPlayer(screen)
+
+	// Draw player bullets
+	for _, b := range g.world.PlayerBullets.Items {
+		if b.Active {
+			style := bulletStyles[b.Kind]
+			scale := style.scale
+			if b.Kind == sim.WeaponCharge {
+				// Charged shots grow with their damage
+				scale = 1 + 0.5*float64(b.Damage-1)
+			}
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Scale(scale, scale)
+			op.GeoM.Translate(b.X, b.Y)
+			op.ColorM.Scale(style.tint[0], style.tint[1], style.tint[2], 1)
+			screen.DrawImage(bulletImage, op)
+		}
+	}
+
+	// Draw enemies
+	for _, e := range g.world.Enemies.Items {
+		if e.Active {
+			g.drawFrame(screen, g.enemyFrame(&e), e.X, e.Y, ebiten.ColorM{})
+		}
+	}
+
+	// Draw enemy bullets
+	for _, b := range g.world.EnemyBullets.Items {
+		if b.Active {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(b.X, b.Y)
+			screen.DrawImage(enemyBulletImage, op)
+			if g.settings.OutlineBullets {
+				w, h := float32(enemyBulletImage.Bounds().Dx()), float32(enemyBulletImage.Bounds().Dy())
+				r := w/2 + 3
+				if h > w {
+					r = h/2 + 3
+				}
+				vector.StrokeCircle(screen, float32(b.X)+w/2, float32(b.Y)+h/2, r, 2, outlineColor, true)
+			}
+		}
+	}
+
+	// Draw the boss if it's active
+	if g.world.BossActive && g.world.Boss.Active {
+		g.drawBoss(screen)
+		g.drawBossHealthBar(screen)
+	}
+
+	// Draw the active power-ups
+	for _, p := range g.world.PowerUps.Items {
+		if p.Active {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(p.X, p.Y)
+			tint := powerUpTints[p.Kind]
+			op.ColorM.Scale(tint[0], tint[1], tint[2], 1)
+			screen.DrawImage(powerUpImage, op)
+		}
+	}
+
+	g.drawExplosions(screen)
+	g.drawParticles(screen)
+	g.drawBomb(screen)
+
+	g.drawCharge(screen)
+	g.drawShield(screen)
+
+	// Draw score, lives and buttons
+	g.hud.draw(g, screen)
+}
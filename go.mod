@@ -14,7 +14,7 @@ require (
 	github.com/jezek/xgb v1.1.0 // indirect
 	github.com/tinne26/mpegg v0.0.0-20221111111526-e880e964c49a
 	golang.org/x/exp v0.0.0-20190731235908-ec7cb31e5a56 // indirect
-	golang.org/x/image v0.12.0 // indirect
+	golang.org/x/image v0.12.0
 	golang.org/x/mobile v0.0.0-20230301163155-e0f57694e12c // indirect
 	golang.org/x/sync v0.1.0 // indirect
 	golang.org/x/sys v0.12.0 // indirect
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"ghost/input"
+	"ghost/score"
+	"ghost/ui"
+)
+
+// difficulty names the high-score tables of the game's only difficulty.
+const difficulty = "normal"
+
+// modes lists the game modes in the order the high scores show them.
+var modes = []GameMode{Competition, Story}
+
+func (m GameMode) String() string {
+	if m == Story {
+		return "story"
+	}
+	return "competition"
+}
+
+// scoreTable returns the key of the high-score table of a game mode.
+func scoreTable(mode GameMode) string {
+	return score.TableKey(mode.String(), difficulty)
+}
+
+// persistRuns reports whether runs go into the high scores and save slots.
+// They don't while a replay is recorded or played back, so the screens a
+// replay goes through don't depend on local files.
+func (g *Game) persistRuns() bool {
+	return !g.replaying()
+}
+
+// enterHighScore asks for the player's initials if the score of the run
+// makes it into the high scores, then calls done with its rank from 0, or
+// -1 if it didn't make it.
+func (g *Game) enterHighScore(done func(rank int)) {
+	table := scoreTable(g.gameMode)
+	if !g.persistRuns() || !g.scores.Qualifies(table, g.world.TotalScore()) {
+		done(-1)
+		return
+	}
+	g.pushScene(&initialsScene{done: done})
+}
+
+// initialsScene lets the player enter their initials for a new high score
+// with the direction buttons: up and down change a letter, left and right
+// move between them, and confirm moves on or saves the score after the
+// last letter.
+type initialsScene struct {
+	baseScene
+	letters [score.InitialsLength]byte
+	pos     int
+	done    func(rank int)
+}
+
+func (s *initialsScene) Overlay() bool { return true }
+
+func (s *initialsScene) Enter(g *Game) {
+	for i := range s.letters {
+		s.letters[i] = 'A'
+	}
+}
+
+func (s *initialsScene) Update(g *Game) error {
+	letter := &s.letters[s.pos]
+	switch {
+	case g.repeated(input.Up):
+		*letter = 'A' + (*letter-'A'+1)%26
+	case g.repeated(input.Down):
+		*letter = 'A' + (*letter-'A'+25)%26
+	case g.repeated(input.Left) || g.justPressed(input.Back):
+		if s.pos > 0 {
+			s.pos--
+		}
+	case g.repeated(input.Right):
+		if s.pos < len(s.letters)-1 {
+			s.pos++
+		}
+	case g.justPressed(input.Confirm):
+		if s.pos < len(s.letters)-1 {
+			s.pos++
+			break
+		}
+		rank := g.scores.Add(scoreTable(g.gameMode), score.Entry{
+			Initials: string(s.letters[:]),
+			Score:    g.world.TotalScore(),
+			Date:     time.Now().Unix(),
+		})
+		g.saveScores()
+		g.popScene()
+		s.done(rank)
+	}
+	return nil
+}
+
+func (s *initialsScene) Draw(g *Game, screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, screenWidth, screenHeight, dimColor, false)
+	drawText(screen, g.lang.T("highscore.new", "score", g.world.TotalScore()), 180, 180)
+	for i, l := range s.letters {
+		x := 280 + 30*i
+		drawText(screen, string(l), x, 230)
+		if i == s.pos {
+			vector.DrawFilledRect(screen, float32(x-2), 250, 16, 3, ui.FocusColor, false)
+		}
+	}
+	drawText(screen, g.lang.T("highscore.hint", "confirm", g.keyName(input.Confirm)), 180, 280)
+}
+
+// saveScores keeps the high scores for the next session.
+func (g *Game) saveScores() {
+	if g.scoresFile == "" {
+		return
+	}
+	if err := g.scores.Save(g.scoresFile); err != nil {
+		log.Printf("saving the high scores: %v", err)
+	}
+}
+
+// leaderboardScene shows the high scores of one game mode at a time; left
+// and right switch between the modes.
+type leaderboardScene struct {
+	baseScene
+	mode int // Index into modes
+}
+
+func (s *leaderboardScene) Update(g *Game) error {
+	switch {
+	case g.repeated(input.Left):
+		s.mode = (s.mode + len(modes) - 1) % len(modes)
+	case g.repeated(input.Right):
+		s.mode = (s.mode + 1) % len(modes)
+	case g.justPressed(input.Confirm) || g.justPressed(input.Back) || g.input.JustUnclicked():
+		g.popScene()
+	}
+	return nil
+}
+
+func (s *leaderboardScene) Draw(g *Game, screen *ebiten.Image) {
+	mode := modes[s.mode]
+	drawText(screen, g.lang.T("highscore.title", "mode", g.lang.T("mode."+mode.String())), 180, 60)
+	entries := g.scores.Top(scoreTable(mode))
+	if len(entries) == 0 {
+		drawText(screen, g.lang.T("highscore.empty"), 180, 110)
+	}
+	for i, e := range entries {
+		y := 110 + 24*i
+		drawText(screen, fmt.Sprintf("%d.", i+1), 160, y)
+		drawText(screen, e.Initials, 200, y)
+		drawText(screen, fmt.Sprint(e.Score), 270, y)
+		drawText(screen, e.Time().Format("2006-01-02"), 380, y)
+	}
+	drawText(screen, g.lang.T("highscore.hintBoard", "confirm", g.keyName(input.Confirm)), 180, 400)
+}
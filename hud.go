@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"ghost/input"
+	"ghost/sim"
+	"ghost/ui"
+)
+
+// hud shows the score, lives and active effects during the gameplay along
+// with the pause and home buttons.
+type hud struct {
+	root    *ui.Panel
+	status  *ui.Label
+	effects *ui.Label
+	pause   *ui.Button
+	notice  *ui.Label // Brief message, such as a quicksave being made
+	ticks   int       // Frames left to show the notice for
+}
+
+// noticeDuration is how many frames a notice is shown for.
+const noticeDuration = 2 * 60
+
+func newHUD(g *Game) *hud {
+	h := &hud{
+		status:  &ui.Label{},
+		effects: &ui.Label{Base: ui.Base{Y: 16}},
+		notice:  &ui.Label{Base: ui.Base{Anchor: ui.Bottom, Y: -10}},
+	}
+	h.pause = &ui.Button{
+		Base:  ui.Base{Anchor: ui.TopRight, X: -18, Y: 10},
+		Image: g.pauseImage,
+		OnClick: func() {
+			if g.paused() {
+				g.popScene()
+			} else {
+				g.pushScene(&pauseScene{})
+			}
+		},
+	}
+	home := &ui.Button{
+		Base:  ui.Base{Anchor: ui.TopRight, X: -78, Y: 10},
+		Image: g.startButtonImage,
+		OnClick: func() {
+			g.setScenes(&mainMenuScene{})
+		},
+	}
+	h.root = &ui.Panel{Base: ui.Base{W: screenWidth, H: screenHeight}}
+	h.root.Add(h.status, h.effects, h.notice, h.pause, home)
+	return h
+}
+
+// update refreshes the texts and handles clicks on the buttons.
+func (h *hud) update(g *Game) {
+	top := g.topScene()
+	status := []string{
+		g.lang.T("hud.score", "score", g.world.Score),
+		g.lang.N("hud.lives", g.world.Lives),
+		g.lang.N("hud.bombs", g.world.Player.Bombs),
+		g.lang.T("hud.weapon", "level", g.world.Player.WeaponLevel),
+	}
+	h.status.Text = strings.Join(status, "   ")
+
+	// List the active power-up effects and how long they last
+	var effects []string
+	for _, kind := range sim.PowerUpKinds {
+		if t := g.world.EffectTime(kind); t > 0 {
+			name := g.lang.T("powerup." + string(kind))
+			effects = append(effects, g.lang.T("hud.effect", "name", name, "seconds", fmt.Sprintf("%.0f", t)))
+		}
+	}
+	h.effects.Text = strings.Join(effects, "   ")
+
+	if h.ticks > 0 {
+		h.ticks--
+	} else {
+		h.notice.Text = ""
+	}
+
+	h.pause.Image = g.pauseImage
+	if g.paused() {
+		h.pause.Image = g.resumeImage
+	}
+	g.updateUI(h.root)
+	// The pause action works like the pause button
+	if g.justPressed(input.Pause) && g.topScene() == top {
+		h.pause.OnClick()
+	}
+	if g.topScene() == top {
+		g.updateQuicksave()
+	}
+}
+
+// notify shows text at the bottom of the screen for a moment.
+func (h *hud) notify(text string) {
+	h.notice.Text = text
+	h.ticks = noticeDuration
+}
+
+func (h *hud) draw(g *Game, screen *ebiten.Image) {
+	g.drawUI(h.root, screen)
+}
+
+// uiContext returns the context widgets are updated and drawn with this
+// frame.
+func (g *Game) uiContext() *ui.Context {
+	x, y := ebiten.CursorPosition()
+	return &ui.Context{Input: &g.input, Cursor: image.Pt(x, y), Face: mplusNormalFont}
+}
+
+// updateUI lays out the widget tree w on the screen and updates it.
+func (g *Game) updateUI(w ui.Widget) {
+	ui.Layout(w, image.Rect(0, 0, screenWidth, screenHeight), mplusNormalFont)
+	w.Update(g.uiContext())
+}
+
+func (g *Game) drawUI(w ui.Widget, screen *ebiten.Image) {
+	w.Draw(screen, g.uiContext())
+}
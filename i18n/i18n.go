@@ -0,0 +1,263 @@
+// Package i18n translates the user-visible strings of the game.
+//
+// Every language is a JSON catalog file named after its language tag, e.g.
+// en.json; LoadDir reads all of them from a directory, so a language can be
+// added by dropping in a file. A catalog maps message keys to either a
+// string or, for messages that depend on a count, an object with one string
+// per plural form of the language:
+//
+//	{
+//	  "name": "English",
+//	  "plural": "one-other",
+//	  "messages": {
+//	    "gameover.score": "Score: {score}",
+//	    "hud.lives": {"one": "{n} life", "other": "{n} lives"}
+//	  }
+//	}
+//
+// Placeholders in braces are replaced by the named arguments of T and N.
+// Messages missing from a catalog are looked up in its fallback language,
+// then in the default language, and finally show up as their key.
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Default is the language every fallback chain ends in.
+const Default = "en"
+
+// Catalog holds the messages of one language.
+type Catalog struct {
+	Tag      string             `json:"-"`        // File name without extension, e.g. "uk"
+	Name     string             `json:"name"`     // Name of the language in the language itself
+	Plural   string             `json:"plural"`   // Plural rule, see PluralRules
+	Fallback string             `json:"fallback"` // Tag of the language to take missing messages from
+	Messages map[string]Message `json:"messages"`
+}
+
+// Message is the text of a message by plural form. Messages that don't
+// depend on a count only have the "other" form.
+type Message map[string]string
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*m = Message{"other": s}
+		return nil
+	}
+	var forms map[string]string
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return errors.New("message must be a string or an object of plural forms")
+	}
+	*m = forms
+	return nil
+}
+
+// PluralRules map a count to the name of its plural form, by rule name.
+var PluralRules = map[string]func(n int) string{
+	// No plural forms, e.g. Chinese
+	"none": func(n int) string { return "other" },
+	// English, German, ...
+	"one-other": func(n int) string {
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	},
+	// Ukrainian, Russian, Belarusian
+	"east-slavic": func(n int) string {
+		if n < 0 {
+			n = -n
+		}
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	},
+}
+
+// pluralForms lists the forms each plural rule can pick.
+var pluralForms = map[string][]string{
+	"none":        {"other"},
+	"one-other":   {"one", "other"},
+	"east-slavic": {"one", "few", "many"},
+}
+
+// Load reads and validates the catalog file at name in fsys.
+func Load(fsys fs.FS, name string) (*Catalog, error) {
+	c := &Catalog{Tag: strings.TrimSuffix(path.Base(name), path.Ext(name))}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return c, nil
+}
+
+// Validate reports every problem found in the catalog.
+func (c *Catalog) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if c.Name == "" {
+		fail("catalog has no name")
+	}
+	forms, ok := pluralForms[c.Plural]
+	if !ok {
+		fail("unknown plural rule %q", c.Plural)
+	}
+	for key, m := range c.Messages {
+		if len(m) == 0 {
+			fail("message %q is empty", key)
+		}
+		for form := range m {
+			if ok && form != "other" && !contains(forms, form) {
+				fail("message %q: plural rule %q has no form %q", key, c.Plural, form)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Bundle holds the catalogs of every language.
+type Bundle struct {
+	catalogs map[string]*Catalog
+}
+
+// LoadDir loads every catalog file in the directory dir of fsys.
+func LoadDir(fsys fs.FS, dir string) (*Bundle, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{catalogs: map[string]*Catalog{}}
+	for _, name := range names {
+		c, err := Load(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		b.catalogs[c.Tag] = c
+	}
+	if _, ok := b.catalogs[Default]; !ok {
+		return nil, fmt.Errorf("no catalog for the default language %q in %s", Default, dir)
+	}
+	for _, c := range b.catalogs {
+		if _, ok := b.catalogs[c.Fallback]; c.Fallback != "" && !ok {
+			return nil, fmt.Errorf("%s: unknown fallback language %q", c.Tag, c.Fallback)
+		}
+	}
+	return b, nil
+}
+
+// Languages returns the catalogs of every language ordered by tag.
+func (b *Bundle) Languages() []*Catalog {
+	var list []*Catalog
+	for _, c := range b.catalogs {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Tag < list[j].Tag })
+	return list
+}
+
+// Localizer translates messages into one language.
+type Localizer struct {
+	chain []*Catalog // The language followed by its fallbacks
+}
+
+// Localizer returns the localizer of the language tag, or of the default
+// language if there is no catalog for it.
+func (b *Bundle) Localizer(tag string) *Localizer {
+	l := &Localizer{}
+	seen := map[string]bool{}
+	for tag != "" && !seen[tag] {
+		c, ok := b.catalogs[tag]
+		if !ok {
+			break
+		}
+		seen[tag] = true
+		l.chain = append(l.chain, c)
+		tag = c.Fallback
+	}
+	if !seen[Default] {
+		l.chain = append(l.chain, b.catalogs[Default])
+	}
+	return l
+}
+
+// Tag returns the tag of the language.
+func (l *Localizer) Tag() string {
+	return l.chain[0].Tag
+}
+
+// T translates the message key, replacing its placeholders by args, which
+// are pairs of placeholder names and values.
+func (l *Localizer) T(key string, args ...any) string {
+	for _, c := range l.chain {
+		if m, ok := c.Messages[key]; ok {
+			if s, ok := m["other"]; ok {
+				return format(s, args)
+			}
+		}
+	}
+	return key
+}
+
+// N translates the message key in the plural form for the count n. The
+// count replaces the placeholder {n}.
+func (l *Localizer) N(key string, n int, args ...any) string {
+	args = append([]any{"n", n}, args...)
+	for _, c := range l.chain {
+		m, ok := c.Messages[key]
+		if !ok {
+			continue
+		}
+		if s, ok := m[PluralRules[c.Plural](n)]; ok {
+			return format(s, args)
+		}
+		if s, ok := m["other"]; ok {
+			return format(s, args)
+		}
+	}
+	return key
+}
+
+func format(s string, args []any) string {
+	if len(args) == 0 {
+		return s
+	}
+	var pairs []string
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("{%v}", args[i]), fmt.Sprint(args[i+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
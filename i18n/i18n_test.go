@@ -0,0 +1,136 @@
+package i18n
+
+import (
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"ghost/assets"
+)
+
+func TestPluralRules(t *testing.T) {
+	tests := []struct {
+		rule string
+		n    int
+		want string
+	}{
+		{"one-other", 0, "other"},
+		{"one-other", 1, "one"},
+		{"one-other", 2, "other"},
+		{"one-other", 11, "other"},
+		{"east-slavic", 1, "one"},
+		{"east-slavic", 2, "few"},
+		{"east-slavic", 3, "few"},
+		{"east-slavic", 4, "few"},
+		{"east-slavic", 0, "many"},
+		{"east-slavic", 5, "many"},
+		{"east-slavic", 10, "many"},
+		{"east-slavic", 11, "many"},
+		{"east-slavic", 12, "many"},
+		{"east-slavic", 14, "many"},
+		{"east-slavic", 20, "many"},
+		{"east-slavic", 21, "one"},
+		{"east-slavic", 22, "few"},
+		{"east-slavic", 111, "many"},
+		{"east-slavic", -1, "one"},
+	}
+	for _, tt := range tests {
+		if got := PluralRules[tt.rule](tt.n); got != tt.want {
+			t.Errorf("%s(%d) = %q, want %q", tt.rule, tt.n, got, tt.want)
+		}
+	}
+}
+
+var testCatalogs = fstest.MapFS{
+	"i18n/en.json": {Data: []byte(`{
+		"name": "English",
+		"plural": "one-other",
+		"messages": {
+			"greeting": "Hello, {name}!",
+			"only.en": "English only",
+			"lives": {"one": "{n} life", "other": "{n} lives"}
+		}
+	}`)},
+	"i18n/uk.json": {Data: []byte(`{
+		"name": "Українська",
+		"plural": "east-slavic",
+		"fallback": "be",
+		"messages": {
+			"greeting": "Привіт, {name}!",
+			"lives": {"one": "{n} життя", "few": "{n} життя", "many": "{n} життів"}
+		}
+	}`)},
+	"i18n/be.json": {Data: []byte(`{
+		"name": "Беларуская",
+		"plural": "east-slavic",
+		"messages": {
+			"only.be": "Толькі па-беларуску"
+		}
+	}`)},
+}
+
+func TestLocalizer(t *testing.T) {
+	b, err := LoadDir(testCatalogs, "i18n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uk := b.Localizer("uk")
+	tests := []struct {
+		got, want string
+	}{
+		{uk.T("greeting", "name", "Оксано"), "Привіт, Оксано!"},
+		{uk.T("only.be"), "Толькі па-беларуску"},
+		{uk.T("only.en"), "English only"},
+		{uk.T("missing"), "missing"},
+		{uk.N("lives", 1), "1 життя"},
+		{uk.N("lives", 3), "3 життя"},
+		{uk.N("lives", 11), "11 життів"},
+		{uk.N("lives", 21), "21 життя"},
+		{b.Localizer("en").N("lives", 1), "1 life"},
+		{b.Localizer("en").N("lives", 5), "5 lives"},
+		{b.Localizer("fr").T("greeting", "name", "Ann"), "Hello, Ann!"},
+		{b.Localizer("en").T("greeting"), "Hello, {name}!"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+	if got := b.Localizer("fr").Tag(); got != Default {
+		t.Errorf("unknown language localized as %q, want %q", got, Default)
+	}
+}
+
+// TestCatalogsHaveSameKeys keeps the translations of the game complete.
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	b, err := LoadDir(assets.FS, "i18n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	en := keys(b.catalogs[Default])
+	for _, c := range b.Languages() {
+		got := keys(c)
+		for _, key := range en {
+			if _, ok := c.Messages[key]; !ok {
+				t.Errorf("%s: missing %q", c.Tag, key)
+			}
+		}
+		for _, key := range got {
+			if _, ok := b.catalogs[Default].Messages[key]; !ok {
+				t.Errorf("%s: %q isn't in the %s catalog", c.Tag, key, Default)
+			}
+		}
+	}
+	if _, ok := b.catalogs["uk"]; !ok {
+		t.Errorf("no uk catalog")
+	}
+}
+
+func keys(c *Catalog) []string {
+	var list []string
+	for key := range c.Messages {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
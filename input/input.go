@@ -0,0 +1,107 @@
+// Package input describes the per-frame input the game reacts to,
+// independently of the device it came from.
+package input
+
+import (
+	"fmt"
+
+	"ghost/sim"
+)
+
+// Button is a set of buttons held during a frame. Each button is an action
+// the player can bind keys and gamepad buttons to, except for the menu
+// shortcuts Key1 and Key2.
+type Button uint16
+
+const (
+	Left Button = 1 << iota
+	Right
+	Up
+	Down
+	Confirm
+	Back
+	Key1
+	Key2
+	_ // Formerly E and U, which picked the language. The bits stay
+	_ // reserved so recorded replays keep their meaning.
+	Fire
+	Bomb
+	Pause
+	Quicksave
+	Quickload
+)
+
+// Actions lists the buttons the player can rebind, in the order the
+// controls screen shows them.
+var Actions = []Button{Left, Right, Up, Down, Fire, Bomb, Pause, Confirm, Back, Quicksave, Quickload}
+
+var actionNames = map[Button]string{
+	Left:    "left",
+	Right:   "right",
+	Up:      "up",
+	Down:    "down",
+	Confirm: "confirm",
+	Back:    "back",
+	Fire:    "fire",
+	Bomb:    "bomb",
+	Pause:   "pause",
+
+	Quicksave: "quicksave",
+	Quickload: "quickload",
+}
+
+// String returns the name of an action, as used in the bindings file and
+// the message catalogs.
+func (b Button) String() string {
+	if name, ok := actionNames[b]; ok {
+		return name
+	}
+	return fmt.Sprintf("Button(%#x)", uint16(b))
+}
+
+// ParseAction returns the action with the given name.
+func ParseAction(name string) (Button, bool) {
+	for b, n := range actionNames {
+		if n == name {
+			return b, true
+		}
+	}
+	return 0, false
+}
+
+// State is everything the game reads from the player during one frame.
+type State struct {
+	Buttons Button
+	// Click reports whether the left mouse button is held; the cursor
+	// position is only meaningful while it is.
+	Click            bool
+	CursorX, CursorY int
+	// MoveX and MoveY are how far the analog stick is pushed, from -127
+	// to 127, in addition to the direction buttons.
+	MoveX, MoveY int8
+}
+
+// Pressed reports whether button b is held.
+func (s State) Pressed(b Button) bool {
+	return s.Buttons&b != 0
+}
+
+// Sim translates the state into the input of the simulation.
+func (s State) Sim() sim.Input {
+	return sim.Input{
+		Left:  s.Pressed(Left),
+		Right: s.Pressed(Right),
+		Up:    s.Pressed(Up),
+		Down:  s.Pressed(Down),
+		MoveX: float64(s.MoveX) / 127,
+		MoveY: float64(s.MoveY) / 127,
+		Fire:  s.Pressed(Fire),
+		Bomb:  s.Pressed(Bomb),
+	}
+}
+
+// Clicked reports whether the left mouse button is held inside the
+// rectangle at (x, y) of size w×h.
+func (s State) Clicked(x, y, w, h int) bool {
+	return s.Click && s.CursorX >= x && s.CursorX <= x+w && s.CursorY >= y && s.CursorY <= y+h
+}
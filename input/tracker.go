@@ -0,0 +1,72 @@
+package input
+
+import "math/bits"
+
+// Key repeat timing in frames: a held button repeats after RepeatDelay
+// frames, then every RepeatInterval frames.
+const (
+	RepeatDelay    = 24
+	RepeatInterval = 6
+)
+
+// Tracker follows the input from frame to frame, telling apart buttons that
+// went down, are held or went up, and repeating held buttons the way a
+// keyboard does. It only depends on the states it is fed, so it behaves the
+// same when they come from a replay.
+type Tracker struct {
+	Current  State // Input of this frame
+	Previous State // Input of the previous frame
+
+	held [16]int // Frames each button has been held for, by bit
+}
+
+// Update moves on to the input of the next frame.
+func (t *Tracker) Update(s State) {
+	t.Previous, t.Current = t.Current, s
+	for i := range t.held {
+		if s.Buttons&(1<<i) != 0 {
+			t.held[i]++
+		} else {
+			t.held[i] = 0
+		}
+	}
+}
+
+// Pressed reports whether button b is held.
+func (t *Tracker) Pressed(b Button) bool {
+	return t.Current.Pressed(b)
+}
+
+// JustPressed reports whether button b went down this frame.
+func (t *Tracker) JustPressed(b Button) bool {
+	return t.Current.Pressed(b) && !t.Previous.Pressed(b)
+}
+
+// JustReleased reports whether button b went up this frame.
+func (t *Tracker) JustReleased(b Button) bool {
+	return !t.Current.Pressed(b) && t.Previous.Pressed(b)
+}
+
+// Held returns for how many frames the single button b has been held, 0 if
+// it is up.
+func (t *Tracker) Held(b Button) int {
+	return t.held[bits.TrailingZeros16(uint16(b))%16]
+}
+
+// Repeated reports whether the single button b went down this frame or
+// repeats because it is held.
+func (t *Tracker) Repeated(b Button) bool {
+	n := t.Held(b)
+	return n == 1 || n > RepeatDelay && (n-RepeatDelay-1)%RepeatInterval == 0
+}
+
+// JustClicked reports whether the left mouse button went down this frame.
+func (t *Tracker) JustClicked() bool {
+	return t.Current.Click && !t.Previous.Click
+}
+
+// JustUnclicked reports whether the left mouse button went up this frame.
+// The cursor position is then that of the previous frame.
+func (t *Tracker) JustUnclicked() bool {
+	return !t.Current.Click && t.Previous.Click
+}
@@ -0,0 +1,147 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+)
+
+// frame is what the tracker reports for button Fire after one frame.
+type frame struct {
+	JustPressed, JustReleased, Pressed bool
+	Held                               int
+}
+
+func TestTracker(t *testing.T) {
+	down, up := State{Buttons: Fire}, State{}
+	tests := []struct {
+		name   string
+		states []State
+		want   []frame
+	}{
+		{"idle", []State{up, up}, []frame{{}, {}}},
+		{
+			"tap",
+			[]State{down, up},
+			[]frame{{JustPressed: true, Pressed: true, Held: 1}, {JustReleased: true}},
+		},
+		{
+			"hold",
+			[]State{down, down, down, up},
+			[]frame{
+				{JustPressed: true, Pressed: true, Held: 1},
+				{Pressed: true, Held: 2},
+				{Pressed: true, Held: 3},
+				{JustReleased: true},
+			},
+		},
+		{
+			"press again",
+			[]State{down, up, down},
+			[]frame{
+				{JustPressed: true, Pressed: true, Held: 1},
+				{JustReleased: true},
+				{JustPressed: true, Pressed: true, Held: 1},
+			},
+		},
+		{
+			"other buttons",
+			[]State{{Buttons: Bomb}, {Buttons: Fire | Bomb}, {Buttons: Bomb}},
+			[]frame{
+				{},
+				{JustPressed: true, Pressed: true, Held: 1},
+				{JustReleased: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tr Tracker
+			for i, s := range tt.states {
+				tr.Update(s)
+				got := frame{tr.JustPressed(Fire), tr.JustReleased(Fire), tr.Pressed(Fire), tr.Held(Fire)}
+				if got != tt.want[i] {
+					t.Errorf("frame %d: got %+v, want %+v", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// repeats returns the frames, counted from 1, on which b repeats while the
+// given states are fed in.
+func repeats(states []State, b Button) []int {
+	var tr Tracker
+	var frames []int
+	for i, s := range states {
+		tr.Update(s)
+		if tr.Repeated(b) {
+			frames = append(frames, i+1)
+		}
+	}
+	return frames
+}
+
+// hold returns n frames with the buttons held.
+func hold(buttons Button, n int) []State {
+	states := make([]State, n)
+	for i := range states {
+		states[i].Buttons = buttons
+	}
+	return states
+}
+
+func TestRepeat(t *testing.T) {
+	first := RepeatDelay + 1
+	tests := []struct {
+		name   string
+		states []State
+		want   []int
+	}{
+		{"tap", hold(Down, 1), []int{1}},
+		{"within the delay", hold(Down, RepeatDelay), []int{1}},
+		{"first repeat", hold(Down, first), []int{1, first}},
+		{
+			"repeating",
+			hold(Down, first+3*RepeatInterval),
+			[]int{1, first, first + RepeatInterval, first + 2*RepeatInterval, first + 3*RepeatInterval},
+		},
+		{
+			"released before the delay",
+			append(append(hold(Down, RepeatDelay-1), State{}), hold(Down, 3)...),
+			[]int{1, RepeatDelay + 1},
+		},
+		{
+			"held with another button",
+			append(hold(Down, 10), hold(Down|Fire, RepeatDelay)...),
+			[]int{1, first, first + RepeatInterval},
+		},
+		{"other button", hold(Up, first), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repeats(tt.states, Down); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("repeated on frames %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClicks(t *testing.T) {
+	states := []State{
+		{},
+		{Click: true, CursorX: 10, CursorY: 20},
+		{Click: true, CursorX: 12, CursorY: 20},
+		{},
+	}
+	want := []struct{ clicked, unclicked bool }{{false, false}, {true, false}, {false, false}, {false, true}}
+	var tr Tracker
+	for i, s := range states {
+		tr.Update(s)
+		if tr.JustClicked() != want[i].clicked || tr.JustUnclicked() != want[i].unclicked {
+			t.Errorf("frame %d: clicked %v, unclicked %v, want %+v", i+1, tr.JustClicked(), tr.JustUnclicked(), want[i])
+		}
+	}
+	if tr.Previous.CursorX != 12 {
+		t.Errorf("cursor on release = %d, want where it was last held", tr.Previous.CursorX)
+	}
+}
@@ -0,0 +1,179 @@
+// Package level loads the Story mode chapters from data files.
+//
+// Every chapter is a JSON file; LoadDir reads all of them from a directory
+// in file name order, so a new chapter can be added by dropping in a file.
+// Paths, including those of the backgrounds and videos, are relative to the
+// root of the file system the chapters are loaded from.
+package level
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"ghost/sim"
+)
+
+type Chapter struct {
+	Name       string  `json:"name"`
+	Background string  `json:"background"` // Image scrolled behind every level of the chapter
+	IntroVideo string  `json:"introVideo"` // Played before the first level of the chapter
+	Levels     []Level `json:"levels"`
+}
+
+type Level struct {
+	Name       string          `json:"name"`
+	Background string          `json:"background"` // Overrides the chapter background
+	IntroVideo string          `json:"introVideo"` // Played before the level
+	Waves      []sim.Wave      `json:"waves"`      // Played in order, starting over after the last
+	Goal       Goal            `json:"goal"`
+	Boss       *sim.BossConfig `json:"boss"`
+}
+
+// Goal is the win condition of a level. Reaching the score finishes the
+// level, or summons the boss if the level has one, in which case the level
+// is finished by defeating it.
+type Goal struct {
+	Score int `json:"score"`
+}
+
+// Progress is what a level's goal calls for after a tick.
+type Progress int
+
+const (
+	InProgress   Progress = iota
+	BossSummoned          // The goal score was reached and the boss appeared
+	Completed
+)
+
+// Check applies the goal of the level to w after a tick in which the boss
+// wasn't defeated, which completes the level on its own. It summons the boss
+// once the goal score is reached, or reports the level completed if it has
+// no boss.
+func (l *Level) Check(w *sim.World) Progress {
+	if w.Score < l.Goal.Score {
+		return InProgress
+	}
+	if l.Boss == nil {
+		return Completed
+	}
+	if !w.BossActive {
+		w.SpawnBoss(*l.Boss)
+		return BossSummoned
+	}
+	return InProgress
+}
+
+// Load reads and validates the chapter file at name in fsys.
+func Load(fsys fs.FS, name string) (Chapter, error) {
+	var c Chapter
+	f, err := fsys.Open(name)
+	if err != nil {
+		return c, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("%s: %w", name, err)
+	}
+	c.applyDefaults()
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("%s: %w", name, err)
+	}
+	return c, nil
+}
+
+// LoadDir loads every chapter file in the directory dir of fsys in file
+// name order.
+func LoadDir(fsys fs.FS, dir string) ([]Chapter, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var chapters []Chapter
+	for _, name := range names {
+		c, err := Load(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		chapters = append(chapters, c)
+	}
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no chapters found in %s", dir)
+	}
+	return chapters, nil
+}
+
+// Files returns the paths of the backgrounds and videos the chapter refers
+// to.
+func (c *Chapter) Files() []string {
+	var files []string
+	add := func(path string) {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	add(c.Background)
+	add(c.IntroVideo)
+	for _, l := range c.Levels {
+		add(l.Background)
+		add(l.IntroVideo)
+	}
+	return files
+}
+
+func (c *Chapter) applyDefaults() {
+	for i := range c.Levels {
+		l := &c.Levels[i]
+		if l.Background == "" {
+			l.Background = c.Background
+		}
+	}
+}
+
+// Validate reports every problem found in the chapter.
+func (c *Chapter) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if c.Name == "" {
+		fail("chapter has no name")
+	}
+	if len(c.Levels) == 0 {
+		fail("chapter %q has no levels", c.Name)
+	}
+	for i, l := range c.Levels {
+		name := l.Name
+		if name == "" {
+			fail("level %d has no name", i+1)
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if l.Background == "" {
+			fail("level %s: no background", name)
+		}
+		if l.Goal.Score <= 0 {
+			fail("level %s: goal score must be positive", name)
+		}
+		if len(l.Waves) == 0 {
+			fail("level %s: no waves", name)
+		}
+		for j, wave := range l.Waves {
+			if err := wave.Validate(); err != nil {
+				fail("level %s: wave %d: %w", name, j+1, err)
+			}
+		}
+		if l.Boss != nil {
+			if err := l.Boss.Validate(); err != nil {
+				fail("level %s: %w", name, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
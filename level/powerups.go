@@ -0,0 +1,54 @@
+package level
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+
+	"ghost/sim"
+)
+
+// PowerUps is the power-up catalog file: the parameters of the kinds of
+// power-ups and the drop tables by enemy type. Every entry replaces the
+// built-in one; entries left out keep the built-in values.
+type PowerUps struct {
+	Types map[sim.PowerUpKind]*sim.PowerUpType `json:"types"`
+	Drops map[string][]sim.Drop                `json:"drops"`
+}
+
+// LoadPowerUps reads the power-up catalog at name in fsys into
+// sim.PowerUpTypes and sim.DropTables. An invalid catalog leaves both
+// untouched.
+func LoadPowerUps(fsys fs.FS, name string) error {
+	var p PowerUps
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	types := make(map[sim.PowerUpKind]*sim.PowerUpType)
+	for kind, t := range sim.PowerUpTypes {
+		types[kind] = t
+	}
+	for kind, t := range p.Types {
+		types[kind] = t
+	}
+	drops := make(map[string][]sim.Drop)
+	for enemy, table := range sim.DropTables {
+		drops[enemy] = table
+	}
+	for enemy, table := range p.Drops {
+		drops[enemy] = table
+	}
+	if err := sim.ValidatePowerUps(types, drops); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	sim.PowerUpTypes, sim.DropTables = types, drops
+	return nil
+}
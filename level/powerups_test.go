@@ -0,0 +1,67 @@
+package level
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"ghost/sim"
+)
+
+func TestLoadPowerUpsRejectsNull(t *testing.T) {
+	saved := sim.PowerUpTypes[sim.PowerUpShield]
+
+	fsys := fstest.MapFS{"powerups.json": {Data: []byte(`{"types": {"shield": null}}`)}}
+	if err := LoadPowerUps(fsys, "powerups.json"); err == nil {
+		t.Errorf("accepted a null power-up")
+	}
+	if sim.PowerUpTypes[sim.PowerUpShield] != saved {
+		t.Errorf("stored the null power-up")
+	}
+}
+
+func TestLoadPowerUpsKeepsTablesOnError(t *testing.T) {
+	types, drops := sim.PowerUpTypes, sim.DropTables
+	shield, turret := types[sim.PowerUpShield], drops["turret"]
+
+	fsys := fstest.MapFS{"powerups.json": {Data: []byte(`{
+		"types": {
+			"shield": {"duration": 1, "speedY": 1},
+			"rapid": {"duration": 10, "value": 2, "speedY": 1}
+		},
+		"drops": {"turret": []}
+	}`)}}
+	if err := LoadPowerUps(fsys, "powerups.json"); err == nil {
+		t.Fatalf("accepted a rapid fire that slows the weapons down")
+	}
+	if sim.PowerUpTypes[sim.PowerUpShield] != shield {
+		t.Errorf("stored the shield of a rejected catalog")
+	}
+	if len(sim.DropTables["turret"]) != len(turret) {
+		t.Errorf("stored the turret drops of a rejected catalog")
+	}
+}
+
+func TestLoadPowerUps(t *testing.T) {
+	types, drops := sim.PowerUpTypes, sim.DropTables
+	defer func() { sim.PowerUpTypes, sim.DropTables = types, drops }()
+
+	fsys := fstest.MapFS{"powerups.json": {Data: []byte(`{
+		"types": {"shield": {"duration": 1, "speedY": 2}},
+		"drops": {"turret": []}
+	}`)}}
+	if err := LoadPowerUps(fsys, "powerups.json"); err != nil {
+		t.Fatal(err)
+	}
+	if got := sim.PowerUpTypes[sim.PowerUpShield].SpeedY; got != 2 {
+		t.Errorf("shield speedY = %v, want 2", got)
+	}
+	if got := sim.PowerUpTypes[sim.PowerUpRapid]; got != types[sim.PowerUpRapid] {
+		t.Errorf("rapid = %+v, want the built-in one", got)
+	}
+	if got := sim.DropTables["turret"]; len(got) != 0 {
+		t.Errorf("turret drops = %v, want none", got)
+	}
+	if types[sim.PowerUpShield].SpeedY == 2 {
+		t.Errorf("changed the replaced table")
+	}
+}
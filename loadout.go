@@ -0,0 +1,71 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"ghost/input"
+	"ghost/sim"
+)
+
+var chargeBarColor = color.RGBA{0xc0, 0x80, 0xff, 0xff}
+
+// loadoutScene lets the player pick the weapons for the coming level:
+// left/right picks the primary weapon, up/down the secondary one and Enter
+// confirms.
+type loadoutScene struct {
+	baseScene
+}
+
+func (s *loadoutScene) Update(g *Game) error {
+	cycle := func(choice *int, n int, delta int) {
+		*choice = (*choice + delta + n) % n
+	}
+	if g.repeated(input.Left) {
+		cycle(&g.primaryChoice, len(sim.PrimaryWeapons), -1)
+	}
+	if g.repeated(input.Right) {
+		cycle(&g.primaryChoice, len(sim.PrimaryWeapons), 1)
+	}
+	if g.repeated(input.Up) {
+		cycle(&g.secondaryChoice, len(sim.SecondaryWeapons), -1)
+	}
+	if g.repeated(input.Down) {
+		cycle(&g.secondaryChoice, len(sim.SecondaryWeapons), 1)
+	}
+	if g.justPressed(input.Confirm) {
+		g.world.Player.Loadout = sim.Loadout{
+			Primary:   sim.PrimaryWeapons[g.primaryChoice],
+			Secondary: sim.SecondaryWeapons[g.secondaryChoice],
+		}
+		g.popScene()
+	}
+	return nil
+}
+
+func (s *loadoutScene) Draw(g *Game, screen *ebiten.Image) {
+	drawText(screen, g.lang.T("loadout",
+		"primary", g.weaponName(sim.PrimaryWeapons[g.primaryChoice]),
+		"secondary", g.weaponName(sim.SecondaryWeapons[g.secondaryChoice]),
+		"confirm", g.keyName(input.Confirm)), 0, 0)
+}
+
+// weaponName returns the translated name of a weapon.
+func (g *Game) weaponName(kind sim.WeaponKind) string {
+	if kind == sim.WeaponNone {
+		return g.lang.T("weapon.none")
+	}
+	return g.lang.T("weapon." + string(kind))
+}
+
+// drawCharge shows how far the charge shot is charged.
+func (g *Game) drawCharge(screen *ebiten.Image) {
+	p := &g.world.Player
+	if p.Charge == 0 {
+		return
+	}
+	width := float32(p.Charge) / 60 * 34
+	vector.DrawFilledRect(screen, float32(p.X), float32(p.Y+38), width, 3, chargeBarColor, false)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"ghost/input"
+	"ghost/ui"
+)
+
+// optionsScene lets the player change the settings. Every change is saved
+// right away.
+type optionsScene struct {
+	baseScene
+	root *ui.Panel
+}
+
+func (s *optionsScene) Enter(g *Game) {
+	set := func() { g.saveSettings() }
+	music := &ui.Slider{Base: ui.Base{Anchor: ui.Top, W: 260}, Value: g.settings.MusicVolume, Max: 1, Step: 0.1}
+	sfx := &ui.Slider{Base: ui.Base{Anchor: ui.Top, W: 260}, Value: g.settings.SFXVolume, Max: 1, Step: 0.1}
+	scale := &ui.Slider{Base: ui.Base{Anchor: ui.Top, W: 260}, Value: float64(g.settings.WindowScale), Min: 1, Max: maxWindowScale, Step: 1}
+	label := func() {
+		music.Text = g.lang.T("options.music", "percent", fmt.Sprintf("%.0f", 100*music.Value))
+		sfx.Text = g.lang.T("options.sfx", "percent", fmt.Sprintf("%.0f", 100*sfx.Value))
+		scale.Text = g.lang.T("options.scale", "scale", scale.Value)
+	}
+	label()
+	music.OnChange = func(v float64) {
+		g.settings.MusicVolume = v
+		g.applyVolume()
+		label()
+		set()
+	}
+	sfx.OnChange = func(v float64) {
+		g.settings.SFXVolume = v
+		g.applyVolume()
+		label()
+		set()
+	}
+	scale.OnChange = func(v float64) {
+		g.settings.WindowScale = int(v)
+		g.applyWindow()
+		label()
+		set()
+	}
+
+	toggle := func(text string, value *bool, apply func()) *ui.Toggle {
+		return &ui.Toggle{Base: ui.Base{Anchor: ui.Top, W: 260}, Text: g.lang.T(text), On: *value, OnChange: func(on bool) {
+			*value = on
+			if apply != nil {
+				apply()
+			}
+			set()
+		}}
+	}
+
+	s.root = &ui.Panel{Base: ui.Base{Anchor: ui.Center}, Vertical: true, Spacing: 6}
+	s.root.Add(
+		&ui.Label{Base: ui.Base{Anchor: ui.Top}, Text: g.lang.T("options.title")},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 260}, Text: g.lang.T("options.language", "name", g.languageName()), OnClick: func() {
+			s.nextLanguage(g)
+		}},
+		music, sfx,
+		toggle("options.fullscreen", &g.settings.Fullscreen, g.applyWindow),
+		scale,
+		toggle("options.vsync", &g.settings.VSync, g.applyWindow),
+		toggle("options.reduceFlashes", &g.settings.ReduceFlashes, nil),
+		toggle("options.outlineBullets", &g.settings.OutlineBullets, nil),
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 260}, Text: g.lang.T("menu.controls"), OnClick: func() { g.pushScene(&controlsScene{}) }},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 260}, Text: g.lang.T("menu.back"), OnClick: g.popScene},
+	)
+}
+
+// nextLanguage switches to the next language and shows the menus in it.
+func (s *optionsScene) nextLanguage(g *Game) {
+	languages := g.bundle.Languages()
+	next := 0
+	for i, c := range languages {
+		if c.Tag == g.lang.Tag() {
+			next = (i + 1) % len(languages)
+		}
+	}
+	g.lang = g.bundle.Localizer(languages[next].Tag)
+	g.settings.Language = languages[next].Tag
+	g.saveSettings()
+	g.setScenes(&mainMenuScene{}, &optionsScene{})
+}
+
+func (s *optionsScene) Update(g *Game) error {
+	if g.justPressed(input.Back) {
+		g.popScene()
+		return nil
+	}
+	g.updateUI(s.root)
+	return nil
+}
+
+func (s *optionsScene) Draw(g *Game, screen *ebiten.Image) {
+	g.drawUI(s.root, screen)
+}
@@ -0,0 +1,239 @@
+// Package particle simulates the short-lived particles of visual effects
+// such as explosions, engine exhaust, sparks and glitter.
+//
+// A preset describes the particles of an effect: how many are emitted and
+// how often, how long they live, where they fly, and how their speed, color
+// and scale change over their life along curves. A system holds a fixed
+// number of particles, allocated once, and drops new ones while it is full,
+// so an effect never costs more than the cap however many play at once.
+//
+// Particles are only for show: they draw their randomness from a source of
+// their own, so they don't change the course of the simulation or replays.
+package particle
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Key is a point of a curve.
+type Key struct {
+	T float64 // Fraction of the particle's life, from 0 to 1
+	V float64
+}
+
+// Curve is a value changing over the life of a particle, linearly between
+// its keys, which are in order of T. The value is held before the first key
+// and after the last one, and an empty curve is 1 throughout.
+type Curve []Key
+
+// At returns the value of the curve at t.
+func (c Curve) At(t float64) float64 {
+	if len(c) == 0 {
+		return 1
+	}
+	if t <= c[0].T {
+		return c[0].V
+	}
+	for i := 1; i < len(c); i++ {
+		if a, b := c[i-1], c[i]; t < b.T {
+			return a.V + (b.V-a.V)*(t-a.T)/(b.T-a.T)
+		}
+	}
+	return c[len(c)-1].V
+}
+
+// Color is a color with red, green, blue and alpha from 0 to 1.
+type Color [4]float64
+
+// ColorKey is a point of a gradient.
+type ColorKey struct {
+	T float64 // Fraction of the particle's life, from 0 to 1
+	C Color
+}
+
+// Gradient is a color changing over the life of a particle, the way a Curve
+// does. An empty gradient is white throughout.
+type Gradient []ColorKey
+
+// At returns the color of the gradient at t.
+func (g Gradient) At(t float64) Color {
+	if len(g) == 0 {
+		return Color{1, 1, 1, 1}
+	}
+	if t <= g[0].T {
+		return g[0].C
+	}
+	for i := 1; i < len(g); i++ {
+		if a, b := g[i-1], g[i]; t < b.T {
+			f := (t - a.T) / (b.T - a.T)
+			var c Color
+			for j := range c {
+				c[j] = a.C[j] + (b.C[j]-a.C[j])*f
+			}
+			return c
+		}
+	}
+	return g[len(g)-1].C
+}
+
+// Range is a span values are picked from at random.
+type Range struct {
+	Min, Max float64
+}
+
+func (r Range) pick(rng *rand.Rand) float64 {
+	return r.Min + (r.Max-r.Min)*rng.Float64()
+}
+
+// Preset describes the particles of an effect.
+type Preset struct {
+	Burst    int     // Particles emitted at once
+	Rate     float64 // Particles per second emitted by an emitter
+	Lifetime Range   // Seconds
+	Speed    Range   // Pixels per second
+	Angle    float64 // Direction in radians, 0 to the right and π/2 down
+	Spread   float64 // Radians the direction varies by either way
+	Radius   float64 // Particles start up to this many pixels from the origin
+	Size     Range   // Diameter in pixels
+	// Velocity, Scale and Color change over the life of a particle: the
+	// speed and size are multiplied by Velocity and Scale
+	Velocity Curve
+	Scale    Curve
+	Color    Gradient
+	Additive bool // Add up the colors of overlapping particles, for light
+}
+
+// Particle is a particle of a system.
+type Particle struct {
+	Preset    *Preset
+	X, Y      float64
+	VX, VY    float64 // Pixels per second at the start of its life
+	Size      float64 // Diameter in pixels before scaling
+	Age, Life float64 // Seconds
+	Active    bool
+}
+
+// Progress returns how far the particle is through its life, from 0 to 1.
+func (p *Particle) Progress() float64 {
+	return math.Min(p.Age/p.Life, 1)
+}
+
+// Diameter returns the size of the particle in pixels now.
+func (p *Particle) Diameter() float64 {
+	return p.Size * p.Preset.Scale.At(p.Progress())
+}
+
+// Color returns the color of the particle now.
+func (p *Particle) Color() Color {
+	return p.Preset.Color.At(p.Progress())
+}
+
+// System holds the particles of every effect playing.
+type System struct {
+	// Particles has a fixed length, the cap. Inactive particles stay in it
+	// until reused and must be skipped when iterating.
+	Particles []Particle
+	free      []int // Indices of inactive particles
+	rng       *rand.Rand
+}
+
+// NewSystem returns a system of at most max particles at once.
+func NewSystem(max int, seed int64) *System {
+	s := &System{Particles: make([]Particle, max), free: make([]int, 0, max), rng: rand.New(rand.NewSource(seed))}
+	s.Reset()
+	return s
+}
+
+// Reset removes every particle.
+func (s *System) Reset() {
+	s.free = s.free[:0]
+	for i := len(s.Particles) - 1; i >= 0; i-- {
+		s.Particles[i].Active = false
+		s.free = append(s.free, i)
+	}
+}
+
+// Len returns the number of active particles.
+func (s *System) Len() int {
+	return len(s.Particles) - len(s.free)
+}
+
+// spawn adds a particle of preset p at (x, y), unless the system is full.
+func (s *System) spawn(p *Preset, x, y float64) {
+	n := len(s.free)
+	if n == 0 {
+		return
+	}
+	i := s.free[n-1]
+	s.free = s.free[:n-1]
+
+	angle := p.Angle + p.Spread*(2*s.rng.Float64()-1)
+	speed := p.Speed.pick(s.rng)
+	if p.Radius > 0 {
+		// Spread evenly over the disk rather than bunched at its center
+		a, r := 2*math.Pi*s.rng.Float64(), p.Radius*math.Sqrt(s.rng.Float64())
+		x, y = x+r*math.Cos(a), y+r*math.Sin(a)
+	}
+	s.Particles[i] = Particle{
+		Preset: p,
+		X:      x,
+		Y:      y,
+		VX:     speed * math.Cos(angle),
+		VY:     speed * math.Sin(angle),
+		Size:   p.Size.pick(s.rng),
+		Life:   math.Max(p.Lifetime.pick(s.rng), 1e-3),
+		Active: true,
+	}
+}
+
+// Burst emits the burst of preset p at (x, y).
+func (s *System) Burst(p *Preset, x, y float64) {
+	for i := 0; i < p.Burst; i++ {
+		s.spawn(p, x, y)
+	}
+}
+
+// Update moves the particles on by dt seconds, removing those whose life is
+// over.
+func (s *System) Update(dt float64) {
+	for i := range s.Particles {
+		p := &s.Particles[i]
+		if !p.Active {
+			continue
+		}
+		p.Age += dt
+		if p.Age >= p.Life {
+			p.Active = false
+			s.free = append(s.free, i)
+			continue
+		}
+		v := p.Preset.Velocity.At(p.Progress())
+		p.X += p.VX * v * dt
+		p.Y += p.VY * v * dt
+	}
+}
+
+// Emitter emits the particles of a preset at its rate from a point that
+// may move, such as the engine of an aircraft.
+type Emitter struct {
+	Preset *Preset
+	X, Y   float64
+	carry  float64 // Fraction of a particle left over from the last update
+}
+
+// Emit emits the particles due over dt seconds into s.
+func (e *Emitter) Emit(s *System, dt float64) {
+	e.carry += e.Preset.Rate * dt
+	// Allow for floating point drift so a whole number of particles due
+	// over several updates isn't left one short
+	for ; e.carry >= 1-1e-9; e.carry-- {
+		s.spawn(e.Preset, e.X, e.Y)
+	}
+}
+
+// Stop drops the fraction of a particle carried over, so the emitter
+// starts afresh when it emits again.
+func (e *Emitter) Stop() {
+	e.carry = 0
+}
@@ -0,0 +1,123 @@
+package particle
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCurve(t *testing.T) {
+	c := Curve{{0.2, 1}, {0.6, 3}, {1, 0}}
+	tests := []struct {
+		t, want float64
+	}{
+		{0, 1},
+		{0.2, 1},
+		{0.4, 2},
+		{0.6, 3},
+		{0.8, 1.5},
+		{1, 0},
+		{2, 0},
+	}
+	for _, tt := range tests {
+		if got := c.At(tt.t); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("At(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+	if got := (Curve{}).At(0.5); got != 1 {
+		t.Errorf("empty curve At(0.5) = %v, want 1", got)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	g := Gradient{{0, Color{0, 0, 0, 1}}, {0.5, Color{1, 0.5, 0, 1}}, {1, Color{1, 1, 1, 0}}}
+	tests := []struct {
+		t    float64
+		want Color
+	}{
+		{-1, Color{0, 0, 0, 1}},
+		{0.25, Color{0.5, 0.25, 0, 1}},
+		{0.75, Color{1, 0.75, 0.5, 0.5}},
+		{1.5, Color{1, 1, 1, 0}},
+	}
+	for _, tt := range tests {
+		got := g.At(tt.t)
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+				t.Errorf("At(%v) = %v, want %v", tt.t, got, tt.want)
+				break
+			}
+		}
+	}
+	if got := (Gradient{}).At(0.5); got != (Color{1, 1, 1, 1}) {
+		t.Errorf("empty gradient At(0.5) = %v, want white", got)
+	}
+}
+
+func TestSystemCap(t *testing.T) {
+	s := NewSystem(10, 1)
+	p := &Preset{Burst: 25, Lifetime: Range{1, 1}}
+	s.Burst(p, 0, 0)
+	if got := s.Len(); got != 10 {
+		t.Fatalf("%d particles after a burst of 25, want the cap of 10", got)
+	}
+	s.Burst(p, 0, 0)
+	if got := s.Len(); got != 10 {
+		t.Errorf("%d particles after bursting into a full system, want 10", got)
+	}
+}
+
+func TestSystemReusesSlots(t *testing.T) {
+	s := NewSystem(10, 1)
+	short := &Preset{Burst: 6, Lifetime: Range{0.5, 0.5}}
+	long := &Preset{Burst: 4, Lifetime: Range{2, 2}}
+	s.Burst(short, 0, 0)
+	s.Burst(long, 0, 0)
+	s.Update(1)
+	if got := s.Len(); got != 4 {
+		t.Fatalf("%d particles once the short ones died, want 4", got)
+	}
+	s.Burst(short, 0, 0)
+	if got := s.Len(); got != 10 {
+		t.Errorf("%d particles after reusing the freed slots, want 10", got)
+	}
+	for i, p := range s.Particles {
+		if !p.Active {
+			t.Errorf("slot %d left unused", i)
+		}
+	}
+	s.Reset()
+	if got := s.Len(); got != 0 {
+		t.Errorf("%d particles after a reset, want 0", got)
+	}
+}
+
+func TestEmitterRate(t *testing.T) {
+	tests := []struct {
+		rate  float64
+		ticks int
+		want  int
+	}{
+		{15, 60, 15},  // A quarter of a particle a tick
+		{2.5, 120, 5}, // Less than one a second
+		{90, 60, 90},  // One and a half a tick
+	}
+	for _, tt := range tests {
+		s := NewSystem(1000, 1)
+		e := &Emitter{Preset: &Preset{Rate: tt.rate, Lifetime: Range{100, 100}}}
+		for i := 0; i < tt.ticks; i++ {
+			e.Emit(s, 1.0/60)
+		}
+		if got := s.Len(); got != tt.want {
+			t.Errorf("rate %v emitted %d particles over %d ticks, want %d", tt.rate, got, tt.ticks, tt.want)
+		}
+	}
+
+	s := NewSystem(1000, 1)
+	e := &Emitter{Preset: &Preset{Rate: 30, Lifetime: Range{100, 100}}}
+	e.Emit(s, 1.0/60)
+	e.Stop()
+	e.Emit(s, 1.0/60)
+	if got := s.Len(); got != 0 {
+		t.Errorf("emitted %d particles from half a particle before and after stopping", got)
+	}
+}
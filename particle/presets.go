@@ -0,0 +1,94 @@
+package particle
+
+import "math"
+
+// fire fades from white hot through orange to smoke.
+var fire = Gradient{
+	{0, Color{1, 1, 0.85, 1}},
+	{0.2, Color{1, 0.75, 0.25, 0.9}},
+	{0.6, Color{0.9, 0.3, 0.05, 0.6}},
+	{1, Color{0.3, 0.1, 0.05, 0}},
+}
+
+var (
+	// Explosion is a ball of fire and sparks flying out where an enemy or
+	// the player is destroyed.
+	Explosion = &Preset{
+		Burst:    48,
+		Lifetime: Range{0.35, 0.9},
+		Speed:    Range{30, 170},
+		Spread:   math.Pi,
+		Radius:   6,
+		Size:     Range{4, 10},
+		Velocity: Curve{{0, 1}, {1, 0.1}},
+		Scale:    Curve{{0, 0.6}, {0.2, 1.2}, {1, 0.3}},
+		Color:    fire,
+		Additive: true,
+	}
+
+	// BossExplosion is the larger explosion of the boss.
+	BossExplosion = &Preset{
+		Burst:    220,
+		Lifetime: Range{0.5, 1.6},
+		Speed:    Range{40, 320},
+		Spread:   math.Pi,
+		Radius:   20,
+		Size:     Range{5, 16},
+		Velocity: Curve{{0, 1}, {1, 0.05}},
+		Scale:    Curve{{0, 0.6}, {0.2, 1.3}, {1, 0.3}},
+		Color:    fire,
+		Additive: true,
+	}
+
+	// Exhaust trails behind the engine of the player's aircraft.
+	Exhaust = &Preset{
+		Rate:     90,
+		Lifetime: Range{0.12, 0.3},
+		Speed:    Range{90, 150},
+		Angle:    math.Pi / 2,
+		Spread:   0.2,
+		Radius:   2,
+		Size:     Range{3, 6},
+		Scale:    Curve{{0, 1}, {1, 0.2}},
+		Color: Gradient{
+			{0, Color{0.7, 0.85, 1, 0.9}},
+			{0.3, Color{1, 0.6, 0.2, 0.7}},
+			{1, Color{0.5, 0.2, 0.1, 0}},
+		},
+		Additive: true,
+	}
+
+	// Impact is the sparks of a bullet hitting its target, thrown back the
+	// way the bullet came.
+	Impact = &Preset{
+		Burst:    8,
+		Lifetime: Range{0.08, 0.22},
+		Speed:    Range{60, 200},
+		Angle:    math.Pi / 2,
+		Spread:   1,
+		Size:     Range{2, 3},
+		Velocity: Curve{{0, 1}, {1, 0.3}},
+		Color: Gradient{
+			{0, Color{1, 1, 0.7, 1}},
+			{1, Color{1, 0.5, 0.1, 0}},
+		},
+		Additive: true,
+	}
+
+	// Sparkle glitters around a power-up.
+	Sparkle = &Preset{
+		Rate:     10,
+		Lifetime: Range{0.4, 0.8},
+		Speed:    Range{5, 20},
+		Angle:    -math.Pi / 2,
+		Spread:   math.Pi,
+		Radius:   14,
+		Size:     Range{3, 5},
+		Scale:    Curve{{0, 0}, {0.3, 1}, {1, 0}},
+		Color: Gradient{
+			{0, Color{1, 1, 1, 1}},
+			{1, Color{1, 1, 0.6, 0.6}},
+		},
+		Additive: true,
+	}
+)
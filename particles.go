@@ -0,0 +1,116 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"ghost/particle"
+	"ghost/sim"
+)
+
+// maxParticles caps the particles alive at once.
+const maxParticles = 2048
+
+// particleSize is the diameter of particleImage in pixels.
+const particleSize = 32
+
+// particleImage is a white dot that fades out towards its edge, drawn
+// scaled and tinted for every particle.
+var particleImage *ebiten.Image
+
+func newParticleImage() *ebiten.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, particleSize, particleSize))
+	const r = particleSize / 2
+	for y := 0; y < particleSize; y++ {
+		for x := 0; x < particleSize; x++ {
+			d := math.Hypot(float64(x)+0.5-r, float64(y)+0.5-r) / r
+			a := math.Max(0, 1-d)
+			img.SetNRGBA(x, y, color.NRGBA{0xff, 0xff, 0xff, uint8(255 * a * a)})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// particleEvent starts the effect of a simulation event, if it has one.
+func (g *Game) particleEvent(event sim.Event) {
+	switch event.Kind {
+	case sim.EventBulletImpact:
+		g.particles.Burst(particle.Impact, event.X, event.Y)
+	case sim.EventEnemyDestroyed, sim.EventPlayerHit:
+		g.particles.Burst(particle.Explosion, event.X, event.Y)
+	case sim.EventBossDefeated:
+		g.particles.Burst(particle.BossExplosion, event.X, event.Y)
+	}
+}
+
+// updateParticles emits the exhaust of the player's aircraft and the
+// sparkle of the power-ups, and moves the particles on by one frame.
+func (g *Game) updateParticles() {
+	dt := 1 / float64(ebiten.TPS())
+
+	p := &g.world.Player
+	if p.State == sim.PlayerExploding {
+		g.exhaust.Stop()
+	} else {
+		b := playerImage.Bounds()
+		g.exhaust.X, g.exhaust.Y = p.X+float64(b.Dx())/2, p.Y+float64(b.Dy())
+		g.exhaust.Emit(g.particles, dt)
+	}
+
+	// The emitters follow the slots of the power-up pool
+	for len(g.sparkles) < len(g.world.PowerUps.Items) {
+		g.sparkles = append(g.sparkles, particle.Emitter{Preset: particle.Sparkle})
+	}
+	b := powerUpImage.Bounds()
+	for i := range g.sparkles {
+		e := &g.sparkles[i]
+		if i >= len(g.world.PowerUps.Items) || !g.world.PowerUps.Items[i].Active {
+			e.Stop()
+			continue
+		}
+		pu := &g.world.PowerUps.Items[i]
+		e.X, e.Y = pu.X+float64(b.Dx())/2, pu.Y+float64(b.Dy())/2
+		e.Emit(g.particles, dt)
+	}
+
+	g.particles.Update(dt)
+}
+
+// resetParticles removes the particles of the previous run.
+func (g *Game) resetParticles() {
+	g.particles.Reset()
+	g.exhaust.Stop()
+	g.sparkles = g.sparkles[:0]
+}
+
+// drawParticles draws the particles, those that blend normally first so the
+// additive ones, which draw light, glow on top of them.
+func (g *Game) drawParticles(screen *ebiten.Image) {
+	for _, additive := range []bool{false, true} {
+		op := &ebiten.DrawImageOptions{}
+		if additive {
+			op.Blend = ebiten.BlendLighter
+		}
+		for i := range g.particles.Particles {
+			p := &g.particles.Particles[i]
+			if !p.Active || p.Preset.Additive != additive {
+				continue
+			}
+			d := p.Diameter()
+			c := p.Color()
+			if d <= 0 || c[3] <= 0 {
+				continue
+			}
+			op.GeoM.Reset()
+			op.GeoM.Translate(-particleSize/2, -particleSize/2)
+			op.GeoM.Scale(d/particleSize, d/particleSize)
+			op.GeoM.Translate(p.X, p.Y)
+			op.ColorM.Reset()
+			op.ColorM.Scale(c[0], c[1], c[2], c[3])
+			screen.DrawImage(particleImage, op)
+		}
+	}
+}
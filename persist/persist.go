@@ -0,0 +1,43 @@
+// Package persist writes the files the game keeps between sessions: the
+// settings, high scores, save slots and snapshots.
+//
+// A file is first written in full to a temporary file next to it, which
+// then replaces it, so a crash or a full disk while saving leaves the old
+// file as it was instead of a truncated one.
+package persist
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile replaces the file at path with data, creating its directory.
+func WriteFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		// Temporary files are only readable by their owner
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"ghost/sim"
+)
+
+// drawPlayer draws the player's aircraft according to its state: blinking
+// while respawning and invulnerable, and an explosion when hit.
+func (g *Game) drawPlayer(screen *ebiten.Image) {
+	p := &g.world.Player
+	switch {
+	case p.State == sim.PlayerExploding:
+		anim := g.sprites.sheet.Animations["explosion"]
+		g.drawExplosion(screen, anim.FrameAt(p.ExplosionProgress()*anim.Duration()), p.X+17, p.Y+17)
+	case p.Blink(g.world.Frame) && !g.settings.ReduceFlashes:
+		// Hidden this frame
+	default:
+		var cm ebiten.ColorM
+		if (p.State == sim.PlayerRespawning || p.State == sim.PlayerInvulnerable) && g.settings.ReduceFlashes {
+			// Dimmed instead of blinking
+			cm.Scale(1, 1, 1, 0.5)
+		}
+		g.drawFrame(screen, g.playerAnim.Frame(), p.X, p.Y, cm)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"ghost/sim"
+)
+
+// powerUpTints colors the power-up image for each kind of power-up.
+var powerUpTints = map[sim.PowerUpKind][3]float64{
+	sim.PowerUpLife:   {1, 1, 1},
+	sim.PowerUpWeapon: {1, 0.6, 0.2},
+	sim.PowerUpShield: {0.4, 0.7, 1},
+	sim.PowerUpRapid:  {1, 1, 0.3},
+	sim.PowerUpBomb:   {1, 0.3, 0.3},
+	sim.PowerUpScore:  {0.4, 1, 0.4},
+	sim.PowerUpSlow:   {0.7, 0.5, 1},
+	sim.PowerUpMagnet: {0.6, 0.6, 0.6},
+}
+
+var shieldColor = color.RGBA{0x60, 0xb0, 0xff, 0xc0}
+
+// drawShield draws a bubble around the player while the shield is up. It
+// blinks during the last two seconds.
+func (g *Game) drawShield(screen *ebiten.Image) {
+	t := g.world.EffectTime(sim.PowerUpShield)
+	if t == 0 || t < 2 && g.world.Frame/8%2 == 0 {
+		return
+	}
+	p := &g.world.Player
+	vector.StrokeCircle(screen, float32(p.X+17), float32(p.Y+17), 24, 2, shieldColor, true)
+}
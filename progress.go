@@ -0,0 +1,251 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"ghost/input"
+	"ghost/save"
+	"ghost/ui"
+)
+
+// position returns where in the story the current level is.
+func (g *Game) position() save.Position {
+	return save.Position{Chapter: int(g.storyChapter), Level: int(g.storyLevel)}
+}
+
+// levelName returns the translated name of the level at p; the chapter files
+// name levels by catalog key.
+func (g *Game) levelName(p save.Position) string {
+	return g.lang.T(g.chapters[p.Chapter].Levels[p.Level].Name)
+}
+
+// validPosition reports whether p is a level of the loaded chapters, which
+// may have changed since the slot was saved.
+func (g *Game) validPosition(p save.Position) bool {
+	return p.Chapter >= 0 && p.Chapter < len(g.chapters) && p.Level >= 0 && p.Level < len(g.chapters[p.Chapter].Levels)
+}
+
+// currentSlot returns the save slot being played, nil if progress isn't
+// saved.
+func (g *Game) currentSlot() *save.Slot {
+	if g.slot < 0 || g.gameMode != Story {
+		return nil
+	}
+	return &g.saves.Slots[g.slot]
+}
+
+// checkpoint saves the run so it can be continued from the current level
+// with the lives, score, weapon level and bombs the player has now. During
+// a boss fight it saves the whole world, so the fight continues from now.
+func (g *Game) checkpoint() {
+	slot := g.currentSlot()
+	if slot == nil {
+		return
+	}
+	p := g.position()
+	slot.Unlock(p)
+	slot.Checkpoint = &save.Checkpoint{
+		Position:    p,
+		Lives:       g.world.Lives,
+		Score:       g.world.Score,
+		RunScore:    g.world.RunScore,
+		WeaponLevel: g.world.Player.WeaponLevel,
+		Bombs:       g.world.Player.Bombs,
+	}
+	if g.world.BossActive {
+		state, err := g.world.State()
+		if err != nil {
+			log.Printf("saving the boss fight: %v", err)
+		} else {
+			slot.Checkpoint.World = &state
+		}
+	}
+	g.saveProgress()
+}
+
+// finishLevel records the score of the completed level and unlocks the
+// next one, where the run continues from.
+func (g *Game) finishLevel() {
+	slot := g.currentSlot()
+	if slot == nil {
+		return
+	}
+	slot.Finish(g.position(), g.world.Score)
+	chapter, lvl, ok := g.nextLevel()
+	if !ok {
+		// The story is over
+		slot.Checkpoint = nil
+		g.saveProgress()
+		return
+	}
+	next := save.Position{Chapter: int(chapter), Level: int(lvl)}
+	slot.Unlock(next)
+	slot.Checkpoint = &save.Checkpoint{
+		Position:    next,
+		Lives:       g.world.Lives,
+		RunScore:    g.world.TotalScore(),
+		WeaponLevel: g.world.Player.WeaponLevel,
+		Bombs:       g.world.Player.Bombs,
+	}
+	g.saveProgress()
+}
+
+// loseRun drops the checkpoint once the player has run out of lives.
+func (g *Game) loseRun() {
+	if slot := g.currentSlot(); slot != nil && slot.Checkpoint != nil {
+		slot.Checkpoint = nil
+		g.saveProgress()
+	}
+}
+
+func (g *Game) saveProgress() {
+	g.saves.Slots[g.slot].Saved = time.Now().Unix()
+	if g.savesFile == "" {
+		return
+	}
+	if err := g.saves.Save(g.savesFile); err != nil {
+		log.Printf("saving the progress: %v", err)
+	}
+}
+
+// startStory starts a Story run in a save slot at the level at p; slot -1
+// doesn't save the progress.
+func (g *Game) startStory(slot int, p save.Position) {
+	g.slot = slot
+	g.gameMode = Story
+	g.initializeGame()
+	if !g.validPosition(p) {
+		p = save.Position{}
+	}
+	g.storyChapter, g.storyLevel = StoryChapter(p.Chapter), StoryLevel(p.Level)
+	g.startLevel()
+}
+
+// continueRun continues the run saved in a slot from its checkpoint.
+func (g *Game) continueRun(slot int) {
+	cp := *g.saves.Slots[slot].Checkpoint
+	g.startStory(slot, cp.Position)
+	if g.position() != cp.Position {
+		return // The level is gone, so the story starts over
+	}
+	if cp.World != nil {
+		err := g.world.SetState(*cp.World)
+		if err == nil {
+			g.setScenes(&gameplayScene{})
+			g.checkpoint()
+			return
+		}
+		// The level starts over instead
+		log.Printf("continuing the boss fight: %v", err)
+	}
+	g.world.Lives = cp.Lives
+	g.world.Score = cp.Score
+	g.world.RunScore = cp.RunScore
+	g.world.Player.WeaponLevel = cp.WeaponLevel
+	g.world.Player.Bombs = cp.Bombs
+	g.checkpoint()
+}
+
+// slotsScene lets the player pick the save slot to play Story mode in.
+type slotsScene struct {
+	baseScene
+	root *ui.Panel
+}
+
+func (s *slotsScene) Enter(g *Game) {
+	list := &ui.List{
+		Base: ui.Base{Anchor: ui.Top},
+		OnSelect: func(i int) {
+			if slot := g.saves.Slots[i]; !slot.Used || !g.validPosition(slot.Unlocked) {
+				g.startStory(i, save.Position{})
+			} else {
+				g.pushScene(&levelSelectScene{slot: i})
+			}
+		},
+	}
+	for i, slot := range g.saves.Slots {
+		text := g.lang.T("save.empty", "slot", i+1)
+		if slot.Used && g.validPosition(slot.Unlocked) {
+			name := g.levelName(slot.Unlocked)
+			text = g.lang.T("save.slot", "slot", i+1, "level", name, "date", time.Unix(slot.Saved, 0).Format("2006-01-02 15:04"))
+		}
+		list.Items = append(list.Items, text)
+	}
+	s.root = &ui.Panel{Base: ui.Base{Anchor: ui.Center}, Vertical: true, Spacing: 12}
+	s.root.Add(
+		&ui.Label{Base: ui.Base{Anchor: ui.Top}, Text: g.lang.T("save.title")},
+		list,
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200}, Text: g.lang.T("menu.back"), OnClick: g.popScene},
+	)
+}
+
+func (s *slotsScene) Update(g *Game) error {
+	if g.justPressed(input.Back) {
+		g.popScene()
+		return nil
+	}
+	g.updateUI(s.root)
+	return nil
+}
+
+func (s *slotsScene) Draw(g *Game, screen *ebiten.Image) {
+	g.drawUI(s.root, screen)
+}
+
+// levelSelectScene lets the player continue the run saved in a slot, or
+// start again from any level unlocked in it.
+type levelSelectScene struct {
+	baseScene
+	slot int
+	root *ui.Panel
+}
+
+func (s *levelSelectScene) Enter(g *Game) {
+	slot := &g.saves.Slots[s.slot]
+	var starts []func()
+	list := &ui.List{
+		Base:     ui.Base{Anchor: ui.Top},
+		OnSelect: func(i int) { starts[i]() },
+	}
+	if cp := slot.Checkpoint; cp != nil && g.validPosition(cp.Position) {
+		name := g.levelName(cp.Position)
+		list.Items = append(list.Items, g.lang.N("save.continue", cp.Lives, "level", name))
+		starts = append(starts, func() { g.continueRun(s.slot) })
+	}
+	for c, chapter := range g.chapters {
+		for l := range chapter.Levels {
+			p := save.Position{Chapter: c, Level: l}
+			if !slot.IsUnlocked(p) {
+				continue
+			}
+			text := g.levelName(p)
+			if best := slot.BestScore(p); best > 0 {
+				text = g.lang.T("save.best", "level", text, "score", best)
+			}
+			list.Items = append(list.Items, text)
+			starts = append(starts, func() { g.startStory(s.slot, p) })
+		}
+	}
+	s.root = &ui.Panel{Base: ui.Base{Anchor: ui.Center}, Vertical: true, Spacing: 12}
+	s.root.Add(
+		&ui.Label{Base: ui.Base{Anchor: ui.Top}, Text: g.lang.T("save.levels", "slot", s.slot+1)},
+		list,
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200}, Text: g.lang.T("menu.back"), OnClick: g.popScene},
+	)
+}
+
+func (s *levelSelectScene) Update(g *Game) error {
+	if g.justPressed(input.Back) {
+		g.popScene()
+		return nil
+	}
+	g.updateUI(s.root)
+	return nil
+}
+
+func (s *levelSelectScene) Draw(g *Game, screen *ebiten.Image) {
+	g.drawUI(s.root, screen)
+}
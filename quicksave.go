@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"ghost/input"
+	"ghost/save"
+	"ghost/snapshot"
+)
+
+// snapshot freezes the run being played.
+func (g *Game) snapshot() (*snapshot.Snapshot, error) {
+	state, err := g.world.State()
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot.Snapshot{
+		Mode:    g.gameMode.String(),
+		Chapter: int(g.storyChapter),
+		Level:   int(g.storyLevel),
+		Slot:    g.slot,
+		Seed:    g.seed,
+		World:   state,
+	}, nil
+}
+
+// restore carries on with the run frozen in s.
+func (g *Game) restore(s *snapshot.Snapshot) error {
+	var mode GameMode
+	switch s.Mode {
+	case Competition.String():
+		mode = Competition
+	case Story.String():
+		mode = Story
+		if !g.validPosition(save.Position{Chapter: s.Chapter, Level: s.Level}) {
+			return fmt.Errorf("there is no level %d of chapter %d", s.Level+1, s.Chapter+1)
+		}
+	default:
+		return fmt.Errorf("unknown game mode %q", s.Mode)
+	}
+
+	if err := g.world.SetState(s.World); err != nil {
+		return err
+	}
+
+	g.gameMode = mode
+	g.storyChapter, g.storyLevel = StoryChapter(s.Chapter), StoryLevel(s.Level)
+	g.slot = -1
+	if s.Slot >= 0 && s.Slot < save.Slots && g.persistRuns() {
+		g.slot = s.Slot
+	}
+	if !g.fixedSeed {
+		g.seed = s.Seed
+	}
+	g.startMusic()
+	g.resetAnimations()
+	g.resetParticles()
+	g.bombFlash = 0
+	g.setScenes(&gameplayScene{})
+	return nil
+}
+
+// inRun reports whether a run is being played, paused or not.
+func (g *Game) inRun() bool {
+	for _, s := range g.scenes {
+		if _, ok := s.(*gameplayScene); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// updateQuicksave saves the run, or loads the last quicksave, when the
+// quicksave or quickload action is pressed during the gameplay.
+func (g *Game) updateQuicksave() {
+	if !g.persistRuns() || g.quicksaveFile == "" {
+		return
+	}
+	if g.justPressed(input.Quicksave) {
+		g.quicksave()
+	} else if g.justPressed(input.Quickload) {
+		g.quickload()
+	}
+}
+
+func (g *Game) quicksave() {
+	s, err := g.snapshot()
+	if err == nil {
+		err = s.Save(g.quicksaveFile)
+	}
+	if err != nil {
+		log.Printf("quicksaving: %v", err)
+		g.hud.notify(g.lang.T("quicksave.failed"))
+		return
+	}
+	g.hud.notify(g.lang.T("quicksave.saved"))
+}
+
+func (g *Game) quickload() {
+	s, err := snapshot.Load(g.quicksaveFile)
+	if err == nil {
+		err = g.restore(s)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		g.hud.notify(g.lang.T("quicksave.none"))
+		return
+	}
+	if err != nil {
+		log.Printf("quickloading: %v", err)
+		g.hud.notify(g.lang.T("quicksave.failed"))
+		return
+	}
+	g.hud.notify(g.lang.T("quicksave.loaded"))
+}
+
+// hasQuicksave reports whether there is a quicksave to load.
+func (g *Game) hasQuicksave() bool {
+	if !g.persistRuns() || g.quicksaveFile == "" {
+		return false
+	}
+	_, err := os.Stat(g.quicksaveFile)
+	return err == nil
+}
+
+// saveCrash keeps the run being played when the game crashes, so it can be
+// continued with -load.
+func (g *Game) saveCrash() {
+	if g.crashFile == "" || !g.inRun() {
+		return
+	}
+	s, err := g.snapshot()
+	if err == nil {
+		err = s.Save(g.crashFile)
+	}
+	if err != nil {
+		log.Printf("saving the run before crashing: %v", err)
+		return
+	}
+	log.Printf("the run was saved to %s, start with -load %s to continue it", g.crashFile, g.crashFile)
+}
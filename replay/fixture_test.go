@@ -0,0 +1,95 @@
+package replay_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"ghost/level"
+	"ghost/replay"
+	"ghost/sim"
+)
+
+// The fixtures in testdata hold the input of gameplay only, without the
+// menus and level screens around it, so the tests feed every frame to the
+// world. Story fixtures start the next level as soon as one is completed.
+//
+// A change to the rules that changes how a fixture plays out shows up here;
+// if it is intended, update the expected outcome.
+
+// outcome is how a fixture played out.
+type outcome struct {
+	Frames   int // Frames played until the end or game over
+	Score    int // Score when it ended
+	Lives    int // Lives left
+	GameOver bool
+	Levels   []int // Score of every Story level completed
+}
+
+// play feeds the frames of r to a new world, playing the levels of chapter
+// in Story mode if it isn't nil.
+func play(r *replay.Replay, chapter *level.Chapter) outcome {
+	w := sim.NewWorld(sim.NewSource(r.Seed))
+	var o outcome
+	current := 0
+	if chapter != nil {
+		w.ResetLevel(chapter.Levels[current].Waves)
+	}
+	for i, s := range r.Frames {
+		o.Frames = i + 1
+		completed := false
+		for _, e := range w.Step(s.Sim(), 1.0/sim.TicksPerSecond) {
+			completed = completed || e.Kind == sim.EventBossDefeated && chapter != nil
+		}
+		if w.GameOver() {
+			o.GameOver = true
+			break
+		}
+		if chapter != nil && !completed {
+			completed = chapter.Levels[current].Check(w) == level.Completed
+		}
+		if completed {
+			o.Levels = append(o.Levels, w.Score)
+			if current++; current == len(chapter.Levels) {
+				break
+			}
+			w.ResetLevel(chapter.Levels[current].Waves)
+		}
+	}
+	o.Score, o.Lives = w.Score, w.Lives
+	return o
+}
+
+func TestFixtures(t *testing.T) {
+	chapters, err := level.LoadDir(os.DirFS("../assets"), "levels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file    string
+		chapter *level.Chapter
+		want    outcome
+	}{
+		// Survives for a while, then runs out of lives
+		{"testdata/competition.rpl", nil, outcome{Frames: 8504, Score: 73, GameOver: true}},
+		// Completes every level of the first chapter, defeating the boss
+		// of the last one
+		{"testdata/story.rpl", &chapters[0], outcome{Frames: 3786, Score: 43, Lives: 1, Levels: []int{1, 3, 43}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			r, err := replay.Load(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := play(r, tt.chapter)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("played out as %+v, want %+v", got, tt.want)
+			}
+			// Playing again must give the same outcome
+			if again := play(r, tt.chapter); !reflect.DeepEqual(again, got) {
+				t.Errorf("played out as %+v the second time, %+v the first", again, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package replay records the input of a session frame by frame so it can be
+// played back exactly.
+//
+// A replay file starts with the magic "GHRP", a format version byte and the
+// RNG seed as a varint, followed by runs of identical frames. Each run is
+// the number of frames as a uvarint, the held buttons as a uvarint and a
+// click byte, followed by the cursor position as two varints when the
+// mouse button was held, and the analog stick position as two signed bytes.
+// Version 1 files have no stick position and still load.
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"ghost/input"
+)
+
+const (
+	magic   = "GHRP"
+	version = 2
+)
+
+// MaxFrames caps the length of a replay, four hours at 60 frames per
+// second, so a corrupt run length can't exhaust the memory.
+const MaxFrames = 4 * 60 * 60 * 60
+
+var ErrBadFormat = errors.New("replay: not a replay file")
+
+// Replay is a recorded session.
+type Replay struct {
+	Seed   int64
+	Frames []input.State
+}
+
+// Record appends the input of one frame.
+func (r *Replay) Record(s input.State) {
+	if !s.Click {
+		s.CursorX, s.CursorY = 0, 0
+	}
+	r.Frames = append(r.Frames, s)
+}
+
+// Encode writes r to w in the replay file format.
+func (r *Replay) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(magic)
+	bw.WriteByte(version)
+	buf := make([]byte, binary.MaxVarintLen64)
+	putVarint := func(v int64) {
+		bw.Write(buf[:binary.PutVarint(buf, v)])
+	}
+	putUvarint := func(v uint64) {
+		bw.Write(buf[:binary.PutUvarint(buf, v)])
+	}
+	putVarint(r.Seed)
+	for i := 0; i < len(r.Frames); {
+		s := r.Frames[i]
+		n := 1
+		for i+n < len(r.Frames) && r.Frames[i+n] == s {
+			n++
+		}
+		putUvarint(uint64(n))
+		putUvarint(uint64(s.Buttons))
+		if s.Click {
+			bw.WriteByte(1)
+			putVarint(int64(s.CursorX))
+			putVarint(int64(s.CursorY))
+		} else {
+			bw.WriteByte(0)
+		}
+		bw.WriteByte(byte(s.MoveX))
+		bw.WriteByte(byte(s.MoveY))
+		i += n
+	}
+	return bw.Flush()
+}
+
+// Decode reads a replay written by Encode.
+func Decode(r io.Reader) (*Replay, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil || string(header[:len(magic)]) != magic {
+		return nil, ErrBadFormat
+	}
+	v := header[len(magic)]
+	if v < 1 || v > version {
+		return nil, fmt.Errorf("replay: unsupported version %d", v)
+	}
+	seed, err := binary.ReadVarint(br)
+	if err != nil {
+		return nil, ErrBadFormat
+	}
+	rp := &Replay{Seed: seed}
+	for {
+		n, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return rp, nil
+		}
+		if err != nil {
+			return nil, ErrBadFormat
+		}
+		var s input.State
+		buttons, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, ErrBadFormat
+		}
+		s.Buttons = input.Button(buttons)
+		click, err := br.ReadByte()
+		if err != nil {
+			return nil, ErrBadFormat
+		}
+		if click != 0 {
+			x, errX := binary.ReadVarint(br)
+			y, errY := binary.ReadVarint(br)
+			if errX != nil || errY != nil {
+				return nil, ErrBadFormat
+			}
+			s.Click, s.CursorX, s.CursorY = true, int(x), int(y)
+		}
+		if v >= 2 {
+			var move [2]byte
+			if _, err := io.ReadFull(br, move[:]); err != nil {
+				return nil, ErrBadFormat
+			}
+			s.MoveX, s.MoveY = int8(move[0]), int8(move[1])
+		}
+		if n > uint64(MaxFrames-len(rp.Frames)) {
+			return nil, fmt.Errorf("replay: longer than %d frames", MaxFrames)
+		}
+		for ; n > 0; n-- {
+			rp.Frames = append(rp.Frames, s)
+		}
+	}
+}
+
+// Save writes r to the file at path.
+func (r *Replay) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads the replay file at path.
+func Load(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f)
+}
+
+// Player feeds the frames of a replay back one at a time.
+type Player struct {
+	replay *Replay
+	pos    int
+}
+
+func NewPlayer(r *Replay) *Player {
+	return &Player{replay: r}
+}
+
+// Next returns the input of the next frame, or false once the replay is
+// exhausted.
+func (p *Player) Next() (input.State, bool) {
+	if p.pos >= len(p.replay.Frames) {
+		return input.State{}, false
+	}
+	s := p.replay.Frames[p.pos]
+	p.pos++
+	return s, true
+}
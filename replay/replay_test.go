@@ -0,0 +1,160 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"ghost/input"
+)
+
+func TestRoundTrip(t *testing.T) {
+	r := &Replay{Seed: -1234567890123}
+	frames := []input.State{
+		{},
+		{Buttons: input.Fire},
+		{Buttons: input.Fire},
+		{Buttons: input.Fire | input.Left, MoveX: -127, MoveY: 40},
+		{Buttons: input.Pause, Click: true, CursorX: 600, CursorY: -3},
+		{Buttons: input.Pause, Click: true, CursorX: 600, CursorY: -3},
+		{CursorX: 20, CursorY: 30}, // The cursor is dropped without a click
+		{Buttons: input.Quicksave | input.Quickload, MoveX: 127, MoveY: -127},
+	}
+	for _, s := range frames {
+		r.Record(s)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, r) {
+		t.Errorf("decoded %+v, want %+v", got, r)
+	}
+	if got.Frames[6].CursorX != 0 || got.Frames[6].CursorY != 0 {
+		t.Errorf("recorded the cursor without a click")
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&Replay{Seed: 3}).Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Seed != 3 || len(got.Frames) != 0 {
+		t.Errorf("decoded %+v", got)
+	}
+}
+
+func TestVersion1(t *testing.T) {
+	// Seed 7, then a run of two frames holding Left, without a click and
+	// without the stick position of version 2
+	v1 := []byte{'G', 'H', 'R', 'P', 1, 14, 2, 1, 0}
+	got, err := Decode(bytes.NewReader(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Replay{Seed: 7, Frames: []input.State{{Buttons: input.Left}, {Buttons: input.Left}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+}
+
+// header returns the start of a version 2 file with the given seed.
+func header(seed int64) []byte {
+	return binary.AppendVarint([]byte{'G', 'H', 'R', 'P', version}, seed)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"bad magic", []byte{'G', 'H', 'R', 'X', 2, 0}},
+		{"version 0", []byte{'G', 'H', 'R', 'P', 0, 0}},
+		{"newer version", []byte{'G', 'H', 'R', 'P', version + 1, 0}},
+		{"no seed", []byte{'G', 'H', 'R', 'P', version}},
+		{"run without buttons", append(header(1), 1)},
+		{"run without click", append(header(1), 1, 0)},
+		{"click without cursor", append(header(1), 1, 0, 1, 4)},
+		{"run without stick", append(header(1), 1, 0, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r, err := Decode(bytes.NewReader(tt.data)); err == nil {
+				t.Errorf("decoded %+v", r)
+			}
+		})
+	}
+}
+
+func TestDecodeUnsupportedVersion(t *testing.T) {
+	_, err := Decode(bytes.NewReader([]byte{'G', 'H', 'R', 'P', version + 1, 0}))
+	if err == nil || errors.Is(err, ErrBadFormat) {
+		t.Errorf("got %v, want an unsupported version error", err)
+	}
+}
+
+func TestDecodeTooLong(t *testing.T) {
+	tests := []struct {
+		name string
+		runs []uint64
+	}{
+		{"huge run", []uint64{1 << 62}},
+		{"runs adding up", []uint64{MaxFrames - 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := header(1)
+			for _, n := range tt.runs {
+				data = binary.AppendUvarint(data, n)
+				data = append(data, 0, 0, 0, 0)
+			}
+			start := time.Now()
+			if _, err := Decode(bytes.NewReader(data)); err == nil {
+				t.Errorf("decoded a replay longer than %d frames", MaxFrames)
+			}
+			if d := time.Since(start); d > 5*time.Second {
+				t.Errorf("took %v to reject", d)
+			}
+		})
+	}
+}
+
+func TestMaxFrames(t *testing.T) {
+	data := binary.AppendUvarint(header(1), MaxFrames)
+	data = append(data, 0, 0, 0, 0)
+	r, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Frames) != MaxFrames {
+		t.Errorf("decoded %d frames, want %d", len(r.Frames), MaxFrames)
+	}
+}
+
+func TestPlayer(t *testing.T) {
+	r := &Replay{Frames: []input.State{{Buttons: input.Up}, {Buttons: input.Down}}}
+	p := NewPlayer(r)
+	for i, want := range r.Frames {
+		got, ok := p.Next()
+		if !ok || got != want {
+			t.Errorf("frame %d = %+v, %v, want %+v", i, got, ok, want)
+		}
+	}
+	if _, ok := p.Next(); ok {
+		t.Errorf("frames left after the end")
+	}
+}
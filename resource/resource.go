@@ -0,0 +1,240 @@
+// Package resource finds the assets of the game: images, sounds, videos and
+// data files.
+//
+// The assets are built into the program, and an override directory can
+// replace any of them, or add new ones, file by file, so a mod needs no
+// rebuild. The manifest, manifest.json at the root of the assets, names
+// the images, sounds and data files the game uses by kind:
+//
+//	{
+//	  "image": {"player": {"path": "player.png"}},
+//	  "sound": {"start": {"path": "start.mp3", "optional": true}}
+//	}
+//
+// which lets the game report every missing file at once when it starts.
+// Files are read once and kept, and images are decoded once.
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png" // The images are PNG files
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// Kind is a kind of asset in the manifest.
+type Kind string
+
+const (
+	Image Kind = "image"
+	Sound Kind = "sound"
+	Data  Kind = "data" // Data files and directories
+)
+
+// ManifestPath is where the manifest is among the assets.
+const ManifestPath = "manifest.json"
+
+// Entry is an asset of the manifest.
+type Entry struct {
+	Path     string `json:"path"`
+	Optional bool   `json:"optional"` // The game does without it
+}
+
+// Manifest holds the assets by kind and name.
+type Manifest map[Kind]map[string]Entry
+
+// Missing is an asset that can't be found.
+type Missing struct {
+	Kind     Kind   // Empty for files that aren't in the manifest
+	Name     string // Name in the manifest
+	Path     string
+	Optional bool
+	Err      error
+}
+
+func (m *Missing) Error() string {
+	if m.Kind == "" {
+		return fmt.Sprintf("%s: %v", m.Path, m.Err)
+	}
+	return fmt.Sprintf("%s %q (%s): %v", m.Kind, m.Name, m.Path, m.Err)
+}
+
+func (m *Missing) Unwrap() error { return m.Err }
+
+// Manager loads the assets.
+type Manager struct {
+	fsys     fs.FS
+	manifest Manifest
+	files    map[string][]byte
+	images   map[string]image.Image
+}
+
+// New returns a manager of the built-in assets, with those in the override
+// directory taking their place if it isn't empty.
+func New(builtin fs.FS, override string) (*Manager, error) {
+	fsys := builtin
+	if override != "" {
+		if info, err := os.Stat(override); err != nil {
+			return nil, err
+		} else if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", override)
+		}
+		fsys = overlay{upper: os.DirFS(override), lower: builtin}
+	}
+	m := &Manager{fsys: fsys, files: map[string][]byte{}, images: map[string]image.Image{}}
+	data, err := fs.ReadFile(fsys, ManifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m.manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", ManifestPath, err)
+	}
+	for kind, entries := range m.manifest {
+		for name, e := range entries {
+			if !fs.ValidPath(e.Path) {
+				return nil, fmt.Errorf("%s: %s %q: invalid path %q", ManifestPath, kind, name, e.Path)
+			}
+		}
+	}
+	return m, nil
+}
+
+// FS returns the assets as a file system.
+func (m *Manager) FS() fs.FS {
+	return m.fsys
+}
+
+// Path returns the path of the asset of the given kind and name.
+func (m *Manager) Path(kind Kind, name string) (string, error) {
+	e, ok := m.manifest[kind][name]
+	if !ok {
+		return "", fmt.Errorf("resource: no %s %q in the manifest", kind, name)
+	}
+	return e.Path, nil
+}
+
+// Missing returns the assets of the manifest that can't be found, the
+// required ones first.
+func (m *Manager) Missing() []*Missing {
+	var missing []*Missing
+	for kind, entries := range m.manifest {
+		for name, e := range entries {
+			if _, err := fs.Stat(m.fsys, e.Path); err != nil {
+				missing = append(missing, &Missing{Kind: kind, Name: name, Path: e.Path, Optional: e.Optional, Err: err})
+			}
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		a, b := missing[i], missing[j]
+		if a.Optional != b.Optional {
+			return !a.Optional
+		}
+		return a.Path < b.Path
+	})
+	return missing
+}
+
+// MissingFiles returns the files at paths that can't be found, such as those
+// a level refers to.
+func (m *Manager) MissingFiles(paths ...string) []*Missing {
+	var missing []*Missing
+	for _, path := range paths {
+		if _, err := fs.Stat(m.fsys, path); err != nil {
+			missing = append(missing, &Missing{Path: path, Err: err})
+		}
+	}
+	return missing
+}
+
+// Open opens the file at path for streaming, such as a video.
+func (m *Manager) Open(path string) (fs.File, error) {
+	return m.fsys.Open(path)
+}
+
+// ReadFile returns the contents of the file at path.
+func (m *Manager) ReadFile(path string) ([]byte, error) {
+	if data, ok := m.files[path]; ok {
+		return data, nil
+	}
+	data, err := fs.ReadFile(m.fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	m.files[path] = data
+	return data, nil
+}
+
+// DecodeImage returns the image at path.
+func (m *Manager) DecodeImage(path string) (image.Image, error) {
+	if img, ok := m.images[path]; ok {
+		return img, nil
+	}
+	f, err := m.fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m.images[path] = img
+	return img, nil
+}
+
+// Image returns the image of the manifest with the given name.
+func (m *Manager) Image(name string) (image.Image, error) {
+	path, err := m.Path(Image, name)
+	if err != nil {
+		return nil, err
+	}
+	return m.DecodeImage(path)
+}
+
+// Sound returns the encoded sound of the manifest with the given name.
+func (m *Manager) Sound(name string) ([]byte, error) {
+	path, err := m.Path(Sound, name)
+	if err != nil {
+		return nil, err
+	}
+	return m.ReadFile(path)
+}
+
+// overlay is a file system whose upper layer hides the files of the lower
+// one with the same path. Directories list the files of both.
+type overlay struct {
+	upper, lower fs.FS
+}
+
+func (o overlay) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return f, err
+	}
+	return o.lower.Open(name)
+}
+
+func (o overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	upper, errUpper := fs.ReadDir(o.upper, name)
+	lower, errLower := fs.ReadDir(o.lower, name)
+	if errUpper != nil && errLower != nil {
+		return nil, errLower
+	}
+	entries := map[string]fs.DirEntry{}
+	for _, e := range lower {
+		entries[e.Name()] = e
+	}
+	for _, e := range upper {
+		entries[e.Name()] = e
+	}
+	list := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
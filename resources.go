@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/mp3"
+
+	"ghost/resource"
+)
+
+// spriteImages lists the sprites of the sheet the scenes draw with.
+var spriteImages = []struct {
+	name  string
+	image **ebiten.Image
+}{
+	{"player", &playerImage},
+	{"bullet", &bulletImage},
+	{"enemyBullet", &enemyBulletImage},
+	{"powerUp", &powerUpImage},
+	{"boss", &bossImage},
+}
+
+// reportMissing logs the missing assets that the game does without and
+// stops the game, listing all of them, if any other is missing.
+func reportMissing(missing []*resource.Missing) {
+	var errs []error
+	for _, m := range missing {
+		if m.Optional {
+			log.Printf("%v, going without it", m)
+		} else {
+			errs = append(errs, m)
+		}
+	}
+	if len(errs) > 0 {
+		log.Fatal(errors.Join(append([]error{errors.New("missing assets:")}, errs...)...))
+	}
+}
+
+// loadImage returns the image of the manifest with the given name.
+func loadImage(assets *resource.Manager, name string) *ebiten.Image {
+	src, err := assets.Image(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return ebiten.NewImageFromImage(src)
+}
+
+// sound returns a new player of the sound effect of the manifest with the
+// given name. Sound effects are decoded once and kept in memory.
+func (g *Game) sound(name string) (*audio.Player, error) {
+	pcm, ok := g.sounds[name]
+	if !ok {
+		data, err := g.assets.Sound(name)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := mp3.DecodeWithSampleRate(g.audioContext.SampleRate(), bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if pcm, err = io.ReadAll(stream); err != nil {
+			return nil, err
+		}
+		g.sounds[name] = pcm
+	}
+	return g.audioContext.NewPlayerFromBytes(pcm), nil
+}
+
+// music returns a player of the music of the manifest with the given name,
+// decoded while it plays as it is too long to keep decoded.
+func (g *Game) music(name string) (*audio.Player, error) {
+	data, err := g.assets.Sound(name)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := mp3.DecodeWithSampleRate(g.audioContext.SampleRate(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return g.audioContext.NewPlayer(stream)
+}
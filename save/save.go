@@ -0,0 +1,131 @@
+// Package save keeps the Story mode progress in save slots.
+//
+// Every slot remembers the furthest level reached, the best score of every
+// level and a checkpoint of the run in progress, so a run can be continued
+// after quitting. All slots are saved together in one JSON file.
+package save
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"ghost/persist"
+	"ghost/sim"
+)
+
+// Slots is how many save slots there are.
+const Slots = 3
+
+// Position is a level of the story.
+type Position struct {
+	Chapter int `json:"chapter"`
+	Level   int `json:"level"`
+}
+
+// Before reports whether p comes before q in the story.
+func (p Position) Before(q Position) bool {
+	return p.Chapter < q.Chapter || p.Chapter == q.Chapter && p.Level < q.Level
+}
+
+func (p Position) key() string {
+	return fmt.Sprintf("%d/%d", p.Chapter, p.Level)
+}
+
+// Checkpoint is what a run is continued from: the level being played and
+// the state of the player when the checkpoint was taken. A checkpoint taken
+// during a boss fight also holds the whole world, so continuing goes back
+// into the fight as it was then.
+type Checkpoint struct {
+	Position
+	Lives       int        `json:"lives"`
+	Score       int        `json:"score"`    // Score made in the level so far
+	RunScore    int        `json:"runScore"` // Score of the earlier levels of the run
+	WeaponLevel int        `json:"weaponLevel"`
+	Bombs       int        `json:"bombs"`
+	World       *sim.State `json:"world,omitempty"`
+}
+
+// Slot is the progress of one player.
+type Slot struct {
+	Used       bool           `json:"used"`
+	Unlocked   Position       `json:"unlocked"` // Furthest level reached
+	Best       map[string]int `json:"best"`     // Best score of every finished level
+	Checkpoint *Checkpoint    `json:"checkpoint"`
+	Saved      int64          `json:"saved"` // Unix time of the last change
+}
+
+// Unlock records that the level at p was reached.
+func (s *Slot) Unlock(p Position) {
+	s.Used = true
+	if s.Unlocked.Before(p) {
+		s.Unlocked = p
+	}
+}
+
+// IsUnlocked reports whether the level at p was reached.
+func (s *Slot) IsUnlocked(p Position) bool {
+	return s.Used && !s.Unlocked.Before(p)
+}
+
+// BestScore returns the best score of the level at p, 0 if it was never
+// finished.
+func (s *Slot) BestScore(p Position) int {
+	return s.Best[p.key()]
+}
+
+// Finish records the score made in the level at p, and reports whether it
+// beats the best one.
+func (s *Slot) Finish(p Position, score int) bool {
+	if score <= s.BestScore(p) {
+		return false
+	}
+	if s.Best == nil {
+		s.Best = map[string]int{}
+	}
+	s.Best[p.key()] = score
+	return true
+}
+
+// Store holds every save slot.
+type Store struct {
+	Slots [Slots]Slot `json:"slots"`
+}
+
+// Latest returns the index of the slot most recently saved with a
+// checkpoint, or -1 if there is no run to continue.
+func (st *Store) Latest() int {
+	latest := -1
+	for i, s := range st.Slots {
+		if s.Checkpoint != nil && (latest < 0 || s.Saved > st.Slots[latest].Saved) {
+			latest = i
+		}
+	}
+	return latest
+}
+
+// Save writes st to the file at path, creating its directory.
+func (st *Store) Save(path string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return persist.WriteFile(path, data)
+}
+
+// Load reads the slots saved at path. A missing file gives empty slots.
+func Load(path string) (*Store, error) {
+	st := &Store{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return st, nil
+}
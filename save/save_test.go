@@ -0,0 +1,92 @@
+package save
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestUnlock(t *testing.T) {
+	var s Slot
+	if s.IsUnlocked(Position{}) {
+		t.Errorf("an unused slot has the first level unlocked")
+	}
+	s.Unlock(Position{Chapter: 1, Level: 2})
+	s.Unlock(Position{Chapter: 0, Level: 3})
+	if want := (Position{Chapter: 1, Level: 2}); s.Unlocked != want {
+		t.Errorf("unlocked %+v, want %+v", s.Unlocked, want)
+	}
+	tests := []struct {
+		p    Position
+		want bool
+	}{
+		{Position{}, true},
+		{Position{Chapter: 0, Level: 5}, true},
+		{Position{Chapter: 1, Level: 2}, true},
+		{Position{Chapter: 1, Level: 3}, false},
+		{Position{Chapter: 2, Level: 0}, false},
+	}
+	for _, tt := range tests {
+		if got := s.IsUnlocked(tt.p); got != tt.want {
+			t.Errorf("IsUnlocked(%+v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestFinish(t *testing.T) {
+	var s Slot
+	p := Position{Chapter: 0, Level: 1}
+	if !s.Finish(p, 500) {
+		t.Errorf("the first score wasn't the best")
+	}
+	if s.Finish(p, 500) || s.Finish(p, 400) {
+		t.Errorf("a score no better than the best replaced it")
+	}
+	if !s.Finish(p, 600) {
+		t.Errorf("a better score didn't replace the best")
+	}
+	if got := s.BestScore(p); got != 600 {
+		t.Errorf("best score %d, want 600", got)
+	}
+	if got := s.BestScore(Position{}); got != 0 {
+		t.Errorf("best score of an unfinished level %d, want 0", got)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	var st Store
+	if got := st.Latest(); got != -1 {
+		t.Errorf("Latest of empty slots = %d, want -1", got)
+	}
+	st.Slots[0] = Slot{Used: true, Checkpoint: &Checkpoint{}, Saved: 100}
+	st.Slots[1] = Slot{Used: true, Saved: 300}
+	st.Slots[2] = Slot{Used: true, Checkpoint: &Checkpoint{}, Saved: 200}
+	if got := st.Latest(); got != 2 {
+		t.Errorf("Latest = %d, want 2", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ghost", "saves.json")
+	if st, err := Load(path); err != nil || st.Slots[0].Used {
+		t.Fatalf("missing file loaded as %+v, %v", st, err)
+	}
+
+	var st Store
+	st.Slots[1].Unlock(Position{Chapter: 1})
+	st.Slots[1].Finish(Position{}, 700)
+	st.Slots[1].Checkpoint = &Checkpoint{Position: Position{Chapter: 1}, Lives: 2, RunScore: 700}
+	if err := st.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := got.Slots[1]
+	if !s.IsUnlocked(Position{Chapter: 1}) || s.BestScore(Position{}) != 700 {
+		t.Errorf("loaded %+v", s)
+	}
+	if s.Checkpoint == nil || *s.Checkpoint != *st.Slots[1].Checkpoint {
+		t.Errorf("loaded checkpoint %+v, want %+v", s.Checkpoint, st.Slots[1].Checkpoint)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Scene is one screen of the game. Scenes are kept on a stack: only the top
+// scene is updated, while an overlay scene is drawn on top of the scenes
+// below it, e.g. the pause screen over the gameplay.
+type Scene interface {
+	// Enter is called when the scene is pushed onto the stack.
+	Enter(g *Game)
+	// Exit is called when the scene is removed from the stack.
+	Exit(g *Game)
+	Update(g *Game) error
+	Draw(g *Game, screen *ebiten.Image)
+	// Overlay reports whether the scenes below are drawn first.
+	Overlay() bool
+}
+
+// baseScene provides no-op hooks for scenes to embed.
+type baseScene struct{}
+
+func (baseScene) Enter(g *Game) {}
+
+func (baseScene) Exit(g *Game) {}
+
+func (baseScene) Overlay() bool { return false }
+
+// pushScene puts s on top of the stack.
+func (g *Game) pushScene(s Scene) {
+	g.scenes = append(g.scenes, s)
+	s.Enter(g)
+}
+
+// popScene removes the top scene, uncovering the one below.
+func (g *Game) popScene() {
+	top := g.scenes[len(g.scenes)-1]
+	g.scenes = g.scenes[:len(g.scenes)-1]
+	top.Exit(g)
+}
+
+// setScenes replaces the whole stack by scenes, the last one on top.
+func (g *Game) setScenes(scenes ...Scene) {
+	for len(g.scenes) > 0 {
+		g.popScene()
+	}
+	for _, s := range scenes {
+		g.pushScene(s)
+	}
+}
+
+func (g *Game) topScene() Scene {
+	return g.scenes[len(g.scenes)-1]
+}
+
+// paused reports whether the gameplay is covered by the pause screen.
+func (g *Game) paused() bool {
+	_, ok := g.topScene().(*pauseScene)
+	return ok
+}
+
+// drawScenes draws the top scene together with the scenes it overlays.
+func (g *Game) drawScenes(screen *ebiten.Image) {
+	bottom := len(g.scenes) - 1
+	for bottom > 0 && g.scenes[bottom].Overlay() {
+		bottom--
+	}
+	for _, s := range g.scenes[bottom:] {
+		s.Draw(g, screen)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"image/color"
+	"io/fs"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"ghost/input"
+	"ghost/save"
+	"ghost/ui"
+)
+
+var dimColor = color.NRGBA{0, 0, 0, 0xa0}
+
+// languageScene lets the player pick the language when the game is started
+// for the first time.
+type languageScene struct {
+	baseScene
+	root *ui.Panel
+	list *ui.List
+	hint *ui.Label
+}
+
+func (s *languageScene) Enter(g *Game) {
+	languages := g.bundle.Languages()
+	s.list = &ui.List{
+		Base: ui.Base{Anchor: ui.Top},
+		OnSelect: func(i int) {
+			g.lang = g.bundle.Localizer(languages[i].Tag)
+			g.settings.Language = languages[i].Tag
+			g.saveSettings()
+			g.setScenes(&mainMenuScene{})
+		},
+	}
+	for _, c := range languages {
+		s.list.Items = append(s.list.Items, c.Name)
+	}
+	s.hint = &ui.Label{Base: ui.Base{Anchor: ui.Top}}
+	s.root = &ui.Panel{Base: ui.Base{Anchor: ui.Center}, Vertical: true, Spacing: 12}
+	s.root.Add(s.list, s.hint)
+}
+
+func (s *languageScene) Update(g *Game) error {
+	// Explain the controls in the highlighted language
+	tag := g.bundle.Languages()[s.list.Selected].Tag
+	s.hint.Text = g.bundle.Localizer(tag).T("language.hint", "confirm", g.keyName(input.Confirm))
+	g.updateUI(s.root)
+	return nil
+}
+
+func (s *languageScene) Draw(g *Game, screen *ebiten.Image) {
+	g.drawUI(s.root, screen)
+}
+
+// mainMenuScene lets the player pick the game mode.
+type mainMenuScene struct {
+	baseScene
+	root *ui.Panel
+}
+
+func (s *mainMenuScene) Enter(g *Game) {
+	g.playStartSound()
+	latest := g.saves.Latest()
+	s.root = &ui.Panel{Base: ui.Base{Anchor: ui.Center}, Vertical: true, Spacing: 8}
+	s.root.Add(
+		&ui.Label{Base: ui.Base{Anchor: ui.Top}, Text: g.lang.T("menu.title")},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200, Disabled: latest < 0 || !g.persistRuns()}, Text: g.lang.T("menu.continue"), OnClick: func() { g.continueRun(latest) }},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200, Disabled: !g.hasQuicksave()}, Text: g.lang.T("menu.quickload"), OnClick: g.quickload},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200}, Text: g.lang.T("menu.competition"), OnClick: func() { g.startGame(Competition) }},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200}, Text: g.lang.T("menu.story"), OnClick: func() { g.startGame(Story) }},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200}, Text: g.lang.T("menu.highscores"), OnClick: func() { g.pushScene(&leaderboardScene{}) }},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 200}, Text: g.lang.T("menu.options"), OnClick: func() { g.pushScene(&optionsScene{}) }},
+	)
+}
+
+func (s *mainMenuScene) Exit(g *Game) {
+	// Stop and close the start sound player
+	if g.startSoundPlayer != nil {
+		g.startSoundPlayer.Close()
+		g.startSoundPlayer = nil
+	}
+}
+
+func (s *mainMenuScene) Update(g *Game) error {
+	// Keep the start sound playing while the menu is shown
+	if g.startSoundPlayer != nil && !g.startSoundPlayer.IsPlaying() {
+		g.playStartSound()
+	}
+	if g.justPressed(input.Key1) {
+		g.startGame(Competition)
+	} else if g.justPressed(input.Key2) {
+		g.startGame(Story)
+	} else {
+		g.updateUI(s.root)
+	}
+	return nil
+}
+
+func (s *mainMenuScene) Draw(g *Game, screen *ebiten.Image) {
+	g.drawUI(s.root, screen)
+}
+
+// startGame starts a new game in the given mode. Story mode asks for the
+// save slot to play in first.
+func (g *Game) startGame(mode GameMode) {
+	if mode == Story {
+		if g.persistRuns() {
+			// Pick the save slot first
+			g.pushScene(&slotsScene{})
+		} else {
+			g.startStory(-1, save.Position{})
+		}
+		return
+	}
+	g.gameMode = mode
+	g.initializeGame()
+	g.setScenes(&gameplayScene{})
+}
+
+// playStartSound plays the start sound from the beginning, unless there is
+// none, which was reported when the game started.
+func (g *Game) playStartSound() {
+	if g.startSoundPlayer == nil {
+		p, err := g.sound("start")
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("loading the start sound: %v", err)
+			}
+			return
+		}
+		g.startSoundPlayer = p
+		g.applyVolume()
+	}
+	if err := g.startSoundPlayer.Rewind(); err != nil {
+		log.Printf("rewinding the start sound: %v", err)
+	}
+	g.startSoundPlayer.Play()
+}
+
+// pauseScene halts the gameplay below it and shows the pause menu.
+type pauseScene struct {
+	baseScene
+	menu *ui.Panel
+}
+
+func (s *pauseScene) Overlay() bool { return true }
+
+func (s *pauseScene) Enter(g *Game) {
+	s.menu = &ui.Panel{
+		Base:       ui.Base{Anchor: ui.Center},
+		Vertical:   true,
+		Spacing:    8,
+		Padding:    16,
+		Background: ui.PanelColor,
+	}
+	s.menu.Add(
+		&ui.Label{Base: ui.Base{Anchor: ui.Top}, Text: g.lang.T("pause.title")},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 180}, Text: g.lang.T("pause.resume"), OnClick: g.popScene},
+		&ui.Button{Base: ui.Base{Anchor: ui.Top, W: 180}, Text: g.lang.T("pause.menu"), OnClick: func() { g.setScenes(&mainMenuScene{}) }},
+	)
+}
+
+func (s *pauseScene) Update(g *Game) error {
+	// The pause button and action resume the gameplay
+	g.hud.update(g)
+	if g.topScene() != Scene(s) {
+		return nil
+	}
+	if g.justPressed(input.Back) {
+		g.popScene()
+		return nil
+	}
+	g.updateUI(s.menu)
+	return nil
+}
+
+func (s *pauseScene) Draw(g *Game, screen *ebiten.Image) {
+	g.drawUI(s.menu, screen)
+}
+
+// gameOverScene is shown over the gameplay once the player has run out of
+// lives.
+type gameOverScene struct {
+	baseScene
+	rank int // Rank of the score in the high scores, -1 if it isn't there
+}
+
+func (s *gameOverScene) Overlay() bool { return true }
+
+func (s *gameOverScene) Enter(g *Game) {
+	if g.gameMode == Competition {
+		g.world.AwardBombBonus()
+	}
+	g.loseRun()
+	s.rank = -1
+	g.enterHighScore(func(rank int) { s.rank = rank })
+}
+
+func (s *gameOverScene) Update(g *Game) error {
+	if g.justPressed(input.Confirm) {
+		// Restart
+		g.initializeGame()
+		if g.gameMode == Story {
+			g.startLevel()
+		} else {
+			g.setScenes(&gameplayScene{})
+		}
+	} else if g.justPressed(input.Back) {
+		// Go to start screen
+		g.setScenes(&mainMenuScene{})
+	}
+	return nil
+}
+
+func (s *gameOverScene) Draw(g *Game, screen *ebiten.Image) {
+	vector.DrawFilledRect(screen, 0, 0, screenWidth, screenHeight, dimColor, false)
+	drawText(screen, g.lang.T("gameover", "score", g.world.TotalScore(), "confirm", g.keyName(input.Confirm), "back", g.keyName(input.Back)), 180, 220)
+	if s.rank >= 0 {
+		drawText(screen, g.lang.T("highscore.rank", "rank", s.rank+1), 180, 270)
+	}
+}
+
+// victoryScene is shown once the last Story level is completed.
+type victoryScene struct {
+	baseScene
+	rank int // Rank of the score in the high scores, -1 if it isn't there
+}
+
+func (s *victoryScene) Enter(g *Game) {
+	s.rank = -1
+	g.enterHighScore(func(rank int) { s.rank = rank })
+}
+
+func (s *victoryScene) Update(g *Game) error {
+	if g.justPressed(input.Confirm) || g.justPressed(input.Back) {
+		g.setScenes(&mainMenuScene{})
+	}
+	return nil
+}
+
+func (s *victoryScene) Draw(g *Game, screen *ebiten.Image) {
+	drawText(screen, g.lang.T("victory"), 0, 0)
+	if s.rank >= 0 {
+		drawText(screen, g.lang.T("highscore.rank", "rank", s.rank+1), 0, 40)
+	}
+}
@@ -0,0 +1,142 @@
+// Package score keeps the local high-score tables.
+//
+// There is one table per game mode and difficulty, each holding the best
+// Size scores. The tables are saved as JSON together with an HMAC of their
+// contents, so a file edited by hand is noticed and not loaded. The key is
+// part of the program, which keeps out casual editing but not a determined
+// cheat.
+package score
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"ghost/persist"
+)
+
+// Size is how many scores every table keeps.
+const Size = 10
+
+// InitialsLength is how many letters a player enters with a high score.
+const InitialsLength = 3
+
+var ErrTampered = errors.New("score: the high scores were edited")
+
+// key signs the saved tables.
+var key = []byte("ghost of kyiv high scores")
+
+// Entry is one high score.
+type Entry struct {
+	Initials string `json:"initials"`
+	Score    int    `json:"score"`
+	Date     int64  `json:"date"` // Unix time the score was made
+}
+
+// Time returns when the score was made.
+func (e Entry) Time() time.Time {
+	return time.Unix(e.Date, 0)
+}
+
+// Board holds every high-score table by table key.
+type Board struct {
+	Tables map[string][]Entry `json:"tables"`
+}
+
+// TableKey returns the key of the table of a game mode and difficulty.
+func TableKey(mode, difficulty string) string {
+	return mode + "/" + difficulty
+}
+
+// Top returns the scores of a table, best first.
+func (b *Board) Top(table string) []Entry {
+	return b.Tables[table]
+}
+
+// Qualifies reports whether score makes it into a table.
+func (b *Board) Qualifies(table string, score int) bool {
+	entries := b.Tables[table]
+	return score > 0 && (len(entries) < Size || score > entries[len(entries)-1].Score)
+}
+
+// Add puts e into a table and returns its rank from 0, or -1 if it doesn't
+// qualify. A score equal to an older one ranks below it.
+func (b *Board) Add(table string, e Entry) int {
+	if !b.Qualifies(table, e.Score) {
+		return -1
+	}
+	if b.Tables == nil {
+		b.Tables = map[string][]Entry{}
+	}
+	e.Initials = strings.ToUpper(e.Initials)
+	entries := b.Tables[table]
+	rank := sort.Search(len(entries), func(i int) bool { return entries[i].Score < e.Score })
+	entries = append(entries, Entry{})
+	copy(entries[rank+1:], entries[rank:])
+	entries[rank] = e
+	if len(entries) > Size {
+		entries = entries[:Size]
+	}
+	b.Tables[table] = entries
+	return rank
+}
+
+// file is the saved form of a board.
+type file struct {
+	Tables map[string][]Entry `json:"tables"`
+	MAC    string             `json:"mac"`
+}
+
+func sign(tables map[string][]Entry) (string, error) {
+	data, err := json.Marshal(tables)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Save writes b to the file at path, creating its directory.
+func (b *Board) Save(path string) error {
+	mac, err := sign(b.Tables)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(file{Tables: b.Tables, MAC: mac}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return persist.WriteFile(path, data)
+}
+
+// Load reads the board saved at path. A missing file gives an empty board,
+// and a file whose contents don't match its HMAC gives ErrTampered.
+func Load(path string) (*Board, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Board{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	want, err := sign(f.Tables)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(want), []byte(f.MAC)) {
+		return nil, fmt.Errorf("%s: %w", path, ErrTampered)
+	}
+	return &Board{Tables: f.Tables}, nil
+}
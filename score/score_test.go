@@ -0,0 +1,121 @@
+package score
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const table = "competition/normal"
+
+func TestAddRanks(t *testing.T) {
+	var b Board
+	for i, s := range []int{50, 100, 75, 100} {
+		b.Add(table, Entry{Initials: "p", Score: s, Date: int64(i)})
+	}
+	want := []struct {
+		score int
+		date  int64
+	}{{100, 1}, {100, 3}, {75, 2}, {50, 0}}
+	top := b.Top(table)
+	if len(top) != len(want) {
+		t.Fatalf("got %d scores, want %d", len(top), len(want))
+	}
+	for i, w := range want {
+		if top[i].Score != w.score || top[i].Date != w.date {
+			t.Errorf("rank %d = %+v, want score %d from %d", i, top[i], w.score, w.date)
+		}
+		if top[i].Initials != "P" {
+			t.Errorf("initials %q weren't upper-cased", top[i].Initials)
+		}
+	}
+	if rank := b.Add(table, Entry{Score: 75}); rank != 3 {
+		t.Errorf("tie with rank 2 ranked %d, want 3", rank)
+	}
+}
+
+func fill(b *Board, n int) {
+	for i := 1; i <= n; i++ {
+		b.Add(table, Entry{Score: i * 10})
+	}
+}
+
+func TestQualifies(t *testing.T) {
+	var b Board
+	if b.Qualifies(table, 0) {
+		t.Errorf("a score of 0 qualified")
+	}
+	if !b.Qualifies(table, 1) {
+		t.Errorf("a score didn't qualify for an empty table")
+	}
+	fill(&b, Size-1)
+	if !b.Qualifies(table, 1) {
+		t.Errorf("a score didn't qualify for a table with room left")
+	}
+	fill(&b, 1)
+	lowest := b.Top(table)[Size-1].Score
+	if b.Qualifies(table, lowest) {
+		t.Errorf("a score tying the lowest of a full table qualified")
+	}
+	if !b.Qualifies(table, lowest+1) {
+		t.Errorf("a score above the lowest of a full table didn't qualify")
+	}
+	if !b.Qualifies("story/normal", 1) {
+		t.Errorf("a full table kept a score out of another table")
+	}
+}
+
+func TestAddTruncates(t *testing.T) {
+	var b Board
+	fill(&b, Size)
+	if got := len(b.Top(table)); got != Size {
+		t.Fatalf("exactly full table holds %d scores, want %d", got, Size)
+	}
+	if rank := b.Add(table, Entry{Score: 10}); rank != -1 {
+		t.Errorf("score tying the lowest of a full table ranked %d", rank)
+	}
+	if rank := b.Add(table, Entry{Score: 1000}); rank != 0 {
+		t.Errorf("best score ranked %d, want 0", rank)
+	}
+	top := b.Top(table)
+	if len(top) != Size {
+		t.Fatalf("table holds %d scores, want %d", len(top), Size)
+	}
+	if top[Size-1].Score != 20 {
+		t.Errorf("lowest score is %d, want 20", top[Size-1].Score)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ghost", "scores.json")
+	if b, err := Load(path); err != nil || len(b.Tables) != 0 {
+		t.Fatalf("missing file loaded as %+v, %v", b, err)
+	}
+
+	var b Board
+	b.Add(table, Entry{Initials: "ABC", Score: 1200, Date: 1700000000})
+	if err := b.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if top := got.Top(table); len(top) != 1 || top[0] != b.Top(table)[0] {
+		t.Errorf("loaded %+v, want %+v", top, b.Top(table))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), "1200", "9200", 1)
+	if err := os.WriteFile(path, []byte(tampered), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); !errors.Is(err, ErrTampered) {
+		t.Errorf("loading an edited file gave %v, want %v", err, ErrTampered)
+	}
+}
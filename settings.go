@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+
+	"ghost/persist"
+)
+
+// maxWindowScale is the largest window scale the options offer.
+const maxWindowScale = 4
+
+// settings are the player's preferences, kept between sessions.
+type settings struct {
+	Language    string   `json:"language"` // Tag of the language, empty to ask at startup
+	MusicVolume float64  `json:"musicVolume"`
+	SFXVolume   float64  `json:"sfxVolume"`
+	Fullscreen  bool     `json:"fullscreen"`
+	WindowScale int      `json:"windowScale"` // Window size as a multiple of the screen size
+	VSync       bool     `json:"vsync"`
+	Bindings    bindings `json:"bindings"`
+	Seed        int64    `json:"seed,omitempty"` // Seed of every run, 0 for a new one per run; -seed overrides it
+
+	// Accessibility
+	ReduceFlashes  bool `json:"reduceFlashes"`  // No bomb flash, and the player dims instead of blinking
+	OutlineBullets bool `json:"outlineBullets"` // Ring enemy bullets so they stand out
+}
+
+func defaultSettings() *settings {
+	return &settings{
+		MusicVolume: 1,
+		SFXVolume:   1,
+		WindowScale: 1,
+		VSync:       true,
+		Bindings:    defaultBindings(),
+	}
+}
+
+// settingsPath returns where the settings are kept.
+func settingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ghost", "settings.json"), nil
+}
+
+// loadSettings reads the settings saved at path. Settings missing from the
+// file keep their defaults, and a missing file gives the defaults.
+func loadSettings(path string) (*settings, error) {
+	s := defaultSettings()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return defaultSettings(), fmt.Errorf("%s: %w", path, err)
+	}
+	s.clamp()
+	return s, nil
+}
+
+// clamp brings hand-edited values back into range.
+func (s *settings) clamp() {
+	s.MusicVolume = clampVolume(s.MusicVolume)
+	s.SFXVolume = clampVolume(s.SFXVolume)
+	if s.WindowScale < 1 {
+		s.WindowScale = 1
+	}
+	if s.WindowScale > maxWindowScale {
+		s.WindowScale = maxWindowScale
+	}
+}
+
+func clampVolume(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// save writes the settings to path, creating its directory.
+func (s *settings) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return persist.WriteFile(path, data)
+}
+
+// saveSettings keeps the settings for the next session, unless a replay is
+// recorded or played back with the default settings.
+func (g *Game) saveSettings() {
+	if g.settingsFile == "" || g.replaying() {
+		return
+	}
+	if err := g.settings.save(g.settingsFile); err != nil {
+		log.Printf("saving the settings: %v", err)
+	}
+}
+
+// applyWindow sets up the window as the settings say.
+func (g *Game) applyWindow() {
+	ebiten.SetWindowSize(screenWidth*g.settings.WindowScale, screenHeight*g.settings.WindowScale)
+	ebiten.SetFullscreen(g.settings.Fullscreen)
+	ebiten.SetVsyncEnabled(g.settings.VSync)
+}
+
+// applyVolume sets the volume of every sound that is loaded.
+func (g *Game) applyVolume() {
+	for _, p := range []*audio.Player{g.musicPlayer, g.startSoundPlayer} {
+		if p != nil {
+			p.SetVolume(g.settings.MusicVolume)
+		}
+	}
+	for _, p := range []*audio.Player{g.shootingPlayer, g.bombPlayer} {
+		if p != nil {
+			p.SetVolume(g.settings.SFXVolume)
+		}
+	}
+}
+
+// knownLanguage reports whether there is a catalog for the language tag.
+func (g *Game) knownLanguage(tag string) bool {
+	for _, c := range g.bundle.Languages() {
+		if c.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// languageName returns the name of the current language.
+func (g *Game) languageName() string {
+	for _, c := range g.bundle.Languages() {
+		if c.Tag == g.lang.Tag() {
+			return c.Name
+		}
+	}
+	return g.lang.Tag()
+}
@@ -0,0 +1,47 @@
+package sim
+
+const (
+	// BombStock is the number of bombs the player starts every life with.
+	BombStock = 3
+	// BombBonus is awarded in Competition mode for every bomb left at game
+	// over.
+	BombBonus = 100
+
+	bombDamage = 5
+	// bombInvulnerability is how long the player can't be hit after
+	// dropping a bomb.
+	bombInvulnerability = 2 * TicksPerSecond
+)
+
+// updateBomb drops a bomb when the bomb button goes down.
+func (w *World) updateBomb(in Input) {
+	pressed := in.Bomb && !w.bombHeld
+	w.bombHeld = in.Bomb
+	if pressed && w.Player.Bombs > 0 && w.Player.Controllable() {
+		w.Player.Bombs--
+		w.bomb()
+	}
+}
+
+// bomb clears every enemy bullet, damages every enemy on screen and the
+// boss, and makes the player invulnerable for a moment.
+func (w *World) bomb() {
+	w.EnemyBullets.Reset()
+	w.Player.makeInvulnerable(bombInvulnerability)
+	w.emit(EventBomb, w.Player.X, w.Player.Y)
+	for i := range w.Enemies.Items {
+		if e := &w.Enemies.Items[i]; e.Active && e.Y >= 0 {
+			w.damageEnemy(e, bombDamage)
+		}
+	}
+	if w.BossActive {
+		w.damageBoss(bombDamage)
+	}
+}
+
+// AwardBombBonus adds BombBonus to the score for every bomb left and uses
+// them up.
+func (w *World) AwardBombBonus() {
+	w.Score += w.Player.Bombs * BombBonus
+	w.Player.Bombs = 0
+}
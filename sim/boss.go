@@ -0,0 +1,365 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// AttackPattern is a named boss attack.
+type AttackPattern string
+
+const (
+	AttackRadial AttackPattern = "radial" // Rings of bullets in every direction
+	AttackSpiral AttackPattern = "spiral" // Rotating arms of bullets
+	AttackSpread AttackPattern = "spread" // Fans of bullets aimed at the player
+	AttackLaser  AttackPattern = "laser"  // A beam straight down from the boss
+	AttackSummon AttackPattern = "summon" // Minions joining the fight
+)
+
+var attackPatterns = []AttackPattern{AttackRadial, AttackSpiral, AttackSpread, AttackLaser, AttackSummon}
+
+// Attack is one attack of a boss phase. Every attack is announced for
+// Telegraph seconds, lasts Duration seconds and is followed by Cooldown
+// seconds of rest.
+type Attack struct {
+	Pattern   AttackPattern `json:"pattern"`
+	Telegraph float64       `json:"telegraph"`
+	Duration  float64       `json:"duration"` // 0 fires a single volley
+	Cooldown  float64       `json:"cooldown"`
+
+	Rate        float64 `json:"rate"`    // Volleys per second while the attack lasts
+	Bullets     int     `json:"bullets"` // Bullets per volley, or arms of a spiral
+	BulletSpeed float64 `json:"bulletSpeed"`
+	Spread      float64 `json:"spread"` // Degrees between the bullets of a spread
+	Spin        float64 `json:"spin"`   // Degrees a spiral turns per volley
+
+	Width float64 `json:"width"` // Width of a laser
+
+	Enemy string `json:"enemy"` // Minion type to summon
+	Count int    `json:"count"` // Minions per summon
+}
+
+// BossPhase is a stage of a boss fight. A phase starts once the boss health
+// drops to Below (a fraction of its full health) and cycles through its
+// attacks until the next phase starts.
+type BossPhase struct {
+	Below   float64  `json:"below"`
+	Speed   float64  `json:"speed"` // Maximum wandering speed on each axis
+	Attacks []Attack `json:"attacks"`
+}
+
+// BossConfig describes the boss of a level.
+type BossConfig struct {
+	Name   string      `json:"name"` // Catalog key of the name shown over the health bar
+	Health int         `json:"health"`
+	Hitbox *Hitbox     `json:"hitbox"` // Hitboxes.Boss when omitted
+	Phases []BossPhase `json:"phases"` // In order of decreasing Below; the first starts the fight
+}
+
+// Validate reports every problem found in the boss.
+func (c BossConfig) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if c.Health <= 0 {
+		fail("boss health must be positive")
+	}
+	if c.Hitbox != nil {
+		if err := c.Hitbox.Validate(); err != nil {
+			fail("%w", err)
+		}
+	}
+	if len(c.Phases) == 0 {
+		fail("boss has no phases")
+	}
+	for i, p := range c.Phases {
+		if p.Below <= 0 || p.Below > 1 || (i > 0 && p.Below >= c.Phases[i-1].Below) {
+			fail("phase %d: below must be in (0, 1] and decrease from phase to phase", i+1)
+		}
+		if p.Speed < 0 {
+			fail("phase %d: speed must not be negative", i+1)
+		}
+		if len(p.Attacks) == 0 {
+			fail("phase %d: no attacks", i+1)
+		}
+		for j, a := range p.Attacks {
+			if err := a.validate(); err != nil {
+				fail("phase %d: attack %d: %w", i+1, j+1, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a Attack) validate() error {
+	known := false
+	for _, p := range attackPatterns {
+		known = known || a.Pattern == p
+	}
+	switch {
+	case !known:
+		return fmt.Errorf("unknown pattern %q", a.Pattern)
+	case a.Telegraph < 0 || a.Duration < 0 || a.Cooldown < 0:
+		return errors.New("telegraph, duration and cooldown must not be negative")
+	case a.Duration > 0 && a.Pattern != AttackLaser && (a.Rate <= 0 || a.Rate > TicksPerSecond):
+		return fmt.Errorf("a lasting attack needs a rate between 0 and %d", TicksPerSecond)
+	case a.Pattern == AttackLaser && (a.Width <= 0 || a.Duration <= 0):
+		return errors.New("a laser needs a positive width and duration")
+	case a.Pattern == AttackSummon && (!knownEnemy(a.Enemy) || a.Count <= 0):
+		return errors.New("a summon needs a known enemy type and a positive count")
+	case a.Pattern != AttackLaser && a.Pattern != AttackSummon && (a.Bullets <= 0 || a.BulletSpeed <= 0):
+		return errors.New("bullets and bulletSpeed must be positive")
+	}
+	return nil
+}
+
+// BossStage is where the boss is in the cycle of its current attack.
+type BossStage int
+
+const (
+	BossTelegraph BossStage = iota
+	BossAttacking
+	BossCooldown
+)
+
+type Boss struct {
+	Config         BossConfig
+	X, Y           float64
+	SpeedX, SpeedY float64
+	Active         bool
+	Health         int
+	Phase          int // Index into Config.Phases
+	Attack         int // Index into the attacks of the phase
+	Stage          BossStage
+	StageTicks     int     // Ticks spent in the stage so far
+	SpiralAngle    float64 // Current angle of a spiral in radians
+	Laser          bool    // Whether the laser is firing
+	Flash          int     // Ticks left of the flash after a hit
+}
+
+// CurrentAttack returns the attack the boss is telegraphing, performing or
+// resting from.
+func (b *Boss) CurrentAttack() Attack {
+	return b.Config.Phases[b.Phase].Attacks[b.Attack]
+}
+
+// check reports whether the boss can carry on fighting: its config must be
+// valid, its phase and attack must be among those of the config and its
+// timers must not be negative.
+func (b *Boss) check() error {
+	if err := b.Config.Validate(); err != nil {
+		return err
+	}
+	if b.Phase < 0 || b.Phase >= len(b.Config.Phases) {
+		return fmt.Errorf("phase %d out of range", b.Phase)
+	}
+	if attacks := b.Config.Phases[b.Phase].Attacks; b.Attack < 0 || b.Attack >= len(attacks) {
+		return fmt.Errorf("attack %d out of range", b.Attack)
+	}
+	if b.Stage < BossTelegraph || b.Stage > BossCooldown {
+		return fmt.Errorf("unknown stage %d", b.Stage)
+	}
+	return checkCounters(
+		counter{"stage ticks", b.StageTicks},
+		counter{"flash", b.Flash},
+	)
+}
+
+// Hitbox returns the hitbox of the boss.
+func (b *Boss) Hitbox() Hitbox {
+	if b.Config.Hitbox != nil {
+		return *b.Config.Hitbox
+	}
+	return Hitboxes.Boss
+}
+
+// HealthFraction returns the remaining health between 0 and 1.
+func (b *Boss) HealthFraction() float64 {
+	return float64(b.Health) / float64(b.Config.Health)
+}
+
+// LaserBounds returns the horizontal extent of the laser beam, which
+// reaches from below the boss to the bottom of the screen.
+func (b *Boss) LaserBounds() (left, right float64) {
+	center := b.X + entitySize/2
+	width := b.CurrentAttack().Width
+	return center - width/2, center + width/2
+}
+
+// bossAreaHeight limits the boss to the upper part of the screen.
+const bossAreaHeight = ScreenHeight / 2
+
+// SpawnBoss clears the regular enemies and brings in the boss.
+func (w *World) SpawnBoss(cfg BossConfig) {
+	w.Enemies.Reset()
+	w.EnemyBullets.Reset()
+	w.BossActive = true
+	w.Boss = Boss{
+		Config: cfg,
+		X:      ScreenWidth / 2,
+		Y:      50,
+		Active: true,
+		Health: cfg.Health,
+	}
+}
+
+// updateBoss moves the boss, runs its attacks and resolves player hits. A
+// frozen boss only takes hits. It returns true once the boss has been
+// defeated.
+func (w *World) updateBoss(frozen bool) bool {
+	b := &w.Boss
+	phase := b.Config.Phases[b.Phase]
+	if b.Flash > 0 {
+		b.Flash--
+	}
+
+	// Hold still while aiming and firing a laser so it can be dodged
+	attack := b.CurrentAttack()
+	aiming := attack.Pattern == AttackLaser && b.Stage != BossCooldown
+	if !aiming && !frozen {
+		// Randomly change the boss's direction every few frames
+		if w.Frame%120 == 0 { // Change direction every 2 seconds
+			b.SpeedX = (w.rng.Float64()*2 - 1) * phase.Speed // Random left-right speed
+			b.SpeedY = (w.rng.Float64()*2 - 1) * phase.Speed // Random top-bottom speed
+		}
+
+		// Move the boss
+		b.X += b.SpeedX
+		b.Y += b.SpeedY
+
+		// Ensure boss stays within its area
+		b.X = clamp(b.X, 0, ScreenWidth-entitySize)
+		b.Y = clamp(b.Y, 0, bossAreaHeight-entitySize)
+	}
+
+	if !frozen {
+		w.updateBossAttack(b, attack)
+	}
+
+	// Check for collision with player bullets
+	for j := range w.PlayerBullets.Items {
+		pb := &w.PlayerBullets.Items[j]
+		if pb.Active && overlaps(place(b.Hitbox(), b.X, b.Y), place(pb.Hitbox(), pb.X, pb.Y)) && pb.hit(-1) {
+			w.impact(pb)
+			if w.damageBoss(pb.Damage) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// damageBoss takes damage off the boss health, moving on to later phases
+// as it drops. It returns true once the boss has been defeated.
+func (w *World) damageBoss(damage int) bool {
+	b := &w.Boss
+	b.Health -= damage
+	b.Flash = FlashDuration
+	w.emit(EventBossHit, b.X, b.Y)
+
+	// Check if the boss has been defeated
+	if b.Health <= 0 {
+		b.Active = false
+		b.Laser = false
+		w.BossActive = false
+		w.emit(EventBossDefeated, b.X, b.Y)
+		return true
+	}
+
+	next := b.Phase
+	for next+1 < len(b.Config.Phases) && b.HealthFraction() <= b.Config.Phases[next+1].Below {
+		next++
+	}
+	if next != b.Phase {
+		b.Phase = next
+		b.Attack = 0
+		b.Laser = false
+		b.setStage(BossTelegraph)
+		w.emit(EventBossPhase, b.X, b.Y)
+	}
+	return false
+}
+
+func (b *Boss) setStage(stage BossStage) {
+	b.Stage = stage
+	b.StageTicks = 0
+}
+
+// updateBossAttack advances the telegraph, attack and cooldown cycle of
+// the current attack.
+func (w *World) updateBossAttack(b *Boss, a Attack) {
+	switch b.Stage {
+	case BossTelegraph:
+		if b.StageTicks >= seconds(a.Telegraph) {
+			b.setStage(BossAttacking)
+			b.Laser = a.Pattern == AttackLaser
+		}
+	case BossAttacking:
+		if a.Pattern == AttackLaser {
+			w.updateLaser(b)
+		} else if b.StageTicks == 0 || a.Rate > 0 && b.StageTicks%seconds(1/a.Rate) == 0 {
+			w.bossVolley(b, a)
+		}
+		if b.StageTicks >= seconds(a.Duration) {
+			b.Laser = false
+			b.setStage(BossCooldown)
+		}
+	case BossCooldown:
+		if b.StageTicks >= seconds(a.Cooldown) {
+			b.Attack = (b.Attack + 1) % len(b.Config.Phases[b.Phase].Attacks)
+			b.setStage(BossTelegraph)
+			return
+		}
+	}
+	b.StageTicks++
+}
+
+// bossVolley fires one volley of a bullet pattern or summons minions.
+func (w *World) bossVolley(b *Boss, a Attack) {
+	x, y := b.X+entitySize/2, b.Y+entitySize/2
+	switch a.Pattern {
+	case AttackRadial:
+		for i := 0; i < a.Bullets; i++ {
+			w.fireAt(x, y, 2*math.Pi*float64(i)/float64(a.Bullets), a.BulletSpeed)
+		}
+	case AttackSpiral:
+		for i := 0; i < a.Bullets; i++ {
+			w.fireAt(x, y, b.SpiralAngle+2*math.Pi*float64(i)/float64(a.Bullets), a.BulletSpeed)
+		}
+		b.SpiralAngle += a.Spin * math.Pi / 180
+	case AttackSpread:
+		aim := math.Atan2(w.Player.Y-b.Y, w.Player.X-b.X)
+		spread := a.Spread * math.Pi / 180
+		for i := 0; i < a.Bullets; i++ {
+			w.fireAt(x, y, aim+(float64(i)-float64(a.Bullets-1)/2)*spread, a.BulletSpeed)
+		}
+	case AttackSummon:
+		cx := x
+		w.spawnGroup(Group{
+			Enemy:     a.Enemy,
+			Count:     a.Count,
+			Formation: FormationLine,
+			X:         &cx,
+			Y:         b.Y + entitySize,
+			Spacing:   40,
+			MinSpeed:  2,
+			MaxSpeed:  3,
+		})
+	}
+}
+
+// updateLaser costs the player a life when caught in the beam. The beam
+// stops after a hit.
+func (w *World) updateLaser(b *Boss) {
+	if !b.Laser {
+		return
+	}
+	left, right := b.LaserBounds()
+	top := b.Y + entitySize
+	beam := place(Hitbox{Shape: ShapeBox, W: right - left, H: ScreenHeight - top}, left, top)
+	if overlaps(w.playerBody(), beam) && w.hitPlayer() {
+		b.Laser = false
+	}
+}
@@ -0,0 +1,179 @@
+package sim
+
+import "math"
+
+// Movement is how an enemy type flies across the screen.
+type Movement string
+
+const (
+	MoveStraight Movement = "straight" // Straight down
+	MoveZigZag   Movement = "zigzag"   // Down while sweeping left and right at constant speed
+	MoveSine     Movement = "sine"     // Down along a sine wave
+	MoveDive     Movement = "dive"     // Homes in on the player, accelerating
+	MoveHold     Movement = "hold"     // Flies in, holds position for a while, then leaves
+)
+
+// FirePattern is how an enemy type shoots.
+type FirePattern string
+
+const (
+	FireNone   FirePattern = "none"
+	FireAimed  FirePattern = "aimed"  // One bullet at the player
+	FireSpread FirePattern = "spread" // A fan of bullets centered on the player
+	FireRadial FirePattern = "radial" // A ring of bullets in every direction
+	FireDown   FirePattern = "down"   // Bombs dropped straight down
+)
+
+// EnemyType describes an enemy archetype.
+type EnemyType struct {
+	Sprite string // Name of the sprite the frontend draws the enemy with
+	Health int    // Hits needed to destroy the enemy
+	Score  int    // Awarded for destroying the enemy
+	Hitbox Hitbox
+
+	Movement  Movement
+	Amplitude float64 // Horizontal swing in pixels for zigzag and sine, steering speed for dive
+	Frequency float64 // Swings per second for zigzag and sine
+	HoldY     float64 // Height a holding enemy stops at
+	Hold      float64 // Seconds a holding enemy stays in position
+
+	Fire        FirePattern
+	FireRate    float64 // Average shots per second
+	Shots       int     // Maximum number of shots, 0 for no limit
+	Bullets     int     // Bullets per shot for spread and radial
+	BulletSpeed float64
+}
+
+// EnemyTypes holds every enemy archetype by name.
+var EnemyTypes = map[string]*EnemyType{
+	"fighter": {
+		Sprite: "fighter", Health: 1, Score: 1,
+		Hitbox:   Hitbox{Shape: ShapeBox, X: 3, Y: 3, W: 26, H: 28},
+		Movement: MoveStraight,
+		Fire:     FireAimed, FireRate: 1.2, Shots: 1, BulletSpeed: 1,
+	},
+	"zigzag": {
+		Sprite: "zigzag", Health: 1, Score: 1,
+		Hitbox:   Hitbox{Shape: ShapeBox, X: 3, Y: 3, W: 26, H: 28},
+		Movement: MoveZigZag, Amplitude: 60, Frequency: 0.5,
+		Fire: FireAimed, FireRate: 0.6, Shots: 2, BulletSpeed: 1.5,
+	},
+	"drone": {
+		Sprite: "drone", Health: 1, Score: 1,
+		Hitbox:   Hitbox{Shape: ShapeCircle, X: 16, Y: 17, R: 13},
+		Movement: MoveSine, Amplitude: 80, Frequency: 0.4,
+		Fire: FireSpread, FireRate: 0.3, Shots: 1, Bullets: 3, BulletSpeed: 1.5,
+	},
+	"diver": {
+		Sprite: "diver", Health: 1, Score: 2,
+		Hitbox:   Hitbox{Shape: ShapeBox, X: 3, Y: 3, W: 26, H: 28},
+		Movement: MoveDive, Amplitude: 1.5,
+		Fire: FireNone,
+	},
+	"turret": {
+		Sprite: "turret", Health: 3, Score: 2,
+		Hitbox:   Hitbox{Shape: ShapeCircle, X: 16, Y: 17, R: 15},
+		Movement: MoveHold, HoldY: 80, Hold: 6,
+		Fire: FireRadial, FireRate: 0.5, Bullets: 8, BulletSpeed: 1.5,
+	},
+	"bomber": {
+		Sprite: "bomber", Health: 5, Score: 3,
+		Hitbox:   Hitbox{Shape: ShapeBox, W: 32, H: 34, Mask: "bomber"},
+		Movement: MoveStraight,
+		Fire:     FireDown, FireRate: 1, BulletSpeed: 2,
+	},
+}
+
+// diveMaxSpeed caps how fast a diving enemy falls.
+const diveMaxSpeed = 8
+
+func newEnemy(typ string, x, y, speed float64) Enemy {
+	return Enemy{
+		Type:   typ,
+		X:      x,
+		Y:      y,
+		BaseX:  x,
+		SpeedY: speed,
+		Health: EnemyTypes[typ].Health,
+		Active: true,
+	}
+}
+
+// spawnEnemy brings e into the world with an ID no enemy had before, so
+// that a new enemy taking the slot of a destroyed one counts as another
+// target.
+func (w *World) spawnEnemy(e Enemy) {
+	w.enemyIDs++
+	e.ID = w.enemyIDs
+	w.Enemies.Add(e)
+}
+
+// moveEnemy advances e by one tick according to its movement behavior.
+func (w *World) moveEnemy(e *Enemy, t *EnemyType) {
+	e.Age++
+	age := float64(e.Age) / TicksPerSecond
+	switch t.Movement {
+	case MoveZigZag:
+		// Triangle wave between -1 and 1, starting from the center
+		phase := age*t.Frequency + 0.25
+		tri := 4*math.Abs(phase-math.Floor(phase+0.5)) - 1
+		e.X = e.BaseX + t.Amplitude*tri
+	case MoveSine:
+		e.X = e.BaseX + t.Amplitude*math.Sin(2*math.Pi*t.Frequency*age)
+	case MoveDive:
+		e.X += clamp(w.Player.X-e.X, -t.Amplitude, t.Amplitude)
+		e.SpeedY = math.Min(e.SpeedY*1.02, diveMaxSpeed)
+	case MoveHold:
+		if e.Y >= t.HoldY && e.Held < seconds(t.Hold) {
+			// Stay in position until the hold time runs out
+			e.Held++
+			return
+		}
+	}
+	e.Y += e.SpeedY
+}
+
+// enemyFire lets e shoot according to its fire pattern.
+func (w *World) enemyFire(e *Enemy, t *EnemyType) {
+	if t.Fire == FireNone || (t.Shots > 0 && e.Shots >= t.Shots) {
+		return
+	}
+	if w.rng.Float64() >= t.FireRate/TicksPerSecond {
+		return
+	}
+	centerX := e.X + 17 - 2 // 17 is half of the enemy image width (34/2) and 2 is half of the bullet image width (4/2).
+	aim := math.Atan2(w.Player.Y-e.Y, w.Player.X-e.X)
+	switch t.Fire {
+	case FireAimed:
+		// Create an enemy bullet with the direction towards the player
+		if w.Player.X == e.X && w.Player.Y == e.Y {
+			return
+		}
+		w.fireAt(centerX, e.Y, aim, t.BulletSpeed)
+	case FireSpread:
+		const spread = math.Pi / 12
+		for i := 0; i < t.Bullets; i++ {
+			offset := (float64(i) - float64(t.Bullets-1)/2) * spread
+			w.fireAt(centerX, e.Y, aim+offset, t.BulletSpeed)
+		}
+	case FireRadial:
+		for i := 0; i < t.Bullets; i++ {
+			w.fireAt(centerX, e.Y, 2*math.Pi*float64(i)/float64(t.Bullets), t.BulletSpeed)
+		}
+	case FireDown:
+		w.fireAt(centerX, e.Y, math.Pi/2, t.BulletSpeed)
+	}
+	e.Shots++
+}
+
+// fireAt adds an enemy bullet at (x, y) flying at angle (radians, 0 is to
+// the right, clockwise on screen) with the given speed.
+func (w *World) fireAt(x, y, angle, speed float64) {
+	w.EnemyBullets.Add(EnemyBullet{
+		X:      x,
+		Y:      y,
+		SpeedX: math.Cos(angle) * speed,
+		SpeedY: math.Sin(angle) * speed,
+		Active: true,
+	})
+}
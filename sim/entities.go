@@ -0,0 +1,47 @@
+package sim
+
+type Player struct {
+	X, Y              float64
+	Speed             float64
+	Loadout           Loadout
+	WeaponLevel       int  // From 1 to MaxWeaponLevel
+	AutoFire          bool // Fire without holding the fire button
+	Charge            int  // Ticks the charge shot has been charged for
+	PrimaryCooldown   int
+	SecondaryCooldown int
+	Bombs             int // Bombs left, refilled with every life
+	State             PlayerState
+	StateTicks        int // Ticks left in a timed state
+}
+
+type PlayerBullet struct {
+	Kind           WeaponKind
+	X, Y           float64
+	SpeedX, SpeedY float64
+	Damage         int
+	Pierce         bool  // Carries on after hitting
+	Homing         bool  // Steers towards the nearest target
+	Hits           []int // IDs of the targets a piercing bullet has hit, see hit
+	Active         bool
+}
+
+type Enemy struct {
+	ID     int    // Tells apart the enemies of a run, see spawnEnemy
+	Type   string // Key into EnemyTypes
+	X, Y   float64
+	BaseX  float64 // Horizontal center of the swing for zigzag and sine movement
+	SpeedY float64
+	Health int
+	Age    int // Ticks since spawning
+	Held   int // Ticks spent holding position
+	Shots  int // Shots fired so far
+	Flash  int // Ticks left of the flash after a hit
+	Active bool
+}
+
+type EnemyBullet struct {
+	X, Y   float64
+	SpeedX float64
+	SpeedY float64
+	Active bool
+}
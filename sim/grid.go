@@ -0,0 +1,81 @@
+package sim
+
+import "math"
+
+// gridCellSize is the side of a grid cell in pixels; roughly twice the size
+// of the largest enemy.
+const gridCellSize = 64
+
+// grid is a uniform grid over the screen used as the broad phase for
+// collisions: entities are bucketed by the cells their bounds touch, and
+// only entities sharing a cell are tested against each other.
+type grid struct {
+	cols, rows int
+	cells      [][]int
+	stamps     []int // Last query that reported each entity, to report it once
+	query      int
+}
+
+func newGrid(width, height float64) *grid {
+	g := &grid{
+		cols: int(math.Ceil(width / gridCellSize)),
+		rows: int(math.Ceil(height / gridCellSize)),
+	}
+	g.cells = make([][]int, g.cols*g.rows)
+	return g
+}
+
+func (g *grid) clear() {
+	for i := range g.cells {
+		g.cells[i] = g.cells[i][:0]
+	}
+}
+
+// span returns the range of cells covered by the given bounds, clamped to
+// the grid.
+func (g *grid) span(x0, y0, x1, y1 float64) (c0, r0, c1, r1 int) {
+	cell := func(v float64, n int) int {
+		c := int(math.Floor(v / gridCellSize))
+		if c < 0 {
+			return 0
+		}
+		if c >= n {
+			return n - 1
+		}
+		return c
+	}
+	return cell(x0, g.cols), cell(y0, g.rows), cell(x1, g.cols), cell(y1, g.rows)
+}
+
+// insert adds entity id with the given bounds.
+func (g *grid) insert(id int, x0, y0, x1, y1 float64) {
+	c0, r0, c1, r1 := g.span(x0, y0, x1, y1)
+	for r := r0; r <= r1; r++ {
+		for c := c0; c <= c1; c++ {
+			g.cells[r*g.cols+c] = append(g.cells[r*g.cols+c], id)
+		}
+	}
+	for id >= len(g.stamps) {
+		g.stamps = append(g.stamps, 0)
+	}
+}
+
+// each calls fn once for every entity sharing a cell with the given
+// bounds, stopping early when fn returns false.
+func (g *grid) each(x0, y0, x1, y1 float64, fn func(id int) bool) {
+	g.query++
+	c0, r0, c1, r1 := g.span(x0, y0, x1, y1)
+	for r := r0; r <= r1; r++ {
+		for c := c0; c <= c1; c++ {
+			for _, id := range g.cells[r*g.cols+c] {
+				if g.stamps[id] == g.query {
+					continue
+				}
+				g.stamps[id] = g.query
+				if !fn(id) {
+					return
+				}
+			}
+		}
+	}
+}
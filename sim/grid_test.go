@@ -0,0 +1,81 @@
+package sim
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// query returns the sorted ids the grid reports for the given bounds.
+func query(g *grid, x0, y0, x1, y1 float64) []int {
+	var ids []int
+	g.each(x0, y0, x1, y1, func(id int) bool {
+		ids = append(ids, id)
+		return true
+	})
+	sort.Ints(ids)
+	return ids
+}
+
+func TestGridQuery(t *testing.T) {
+	g := newGrid(4*gridCellSize, 4*gridCellSize)
+	g.insert(0, 10, 10, 20, 20)     // Top left cell
+	g.insert(1, 200, 200, 210, 210) // Bottom right cell
+	g.insert(2, 50, 50, 140, 80)    // Spans three cells of the top two rows
+	g.insert(3, -30, 100, -10, 120) // Off screen, clamped to the left column
+
+	tests := []struct {
+		name           string
+		x0, y0, x1, y1 float64
+		want           []int
+	}{
+		{"empty cell", 140, 140, 150, 150, nil},
+		{"one cell", 0, 0, 5, 5, []int{0, 2}},
+		{"spanning cells", 60, 60, 70, 70, []int{0, 2, 3}},
+		{"far corner", 250, 250, 255, 255, []int{1}},
+		{"whole grid", 0, 0, 4 * gridCellSize, 4 * gridCellSize, []int{0, 1, 2, 3}},
+		{"clamped", 1000, 1000, 2000, 2000, []int{1}},
+		{"left column", 0, 70, 5, 80, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := query(g, tt.x0, tt.y0, tt.x1, tt.y1); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGridReportsOnce(t *testing.T) {
+	g := newGrid(4*gridCellSize, 4*gridCellSize)
+	g.insert(0, 0, 0, 4*gridCellSize, 4*gridCellSize) // In every cell
+	for i := 0; i < 2; i++ {
+		if got := query(g, 0, 0, 4*gridCellSize, 4*gridCellSize); !reflect.DeepEqual(got, []int{0}) {
+			t.Errorf("query %d reported %v, want [0]", i+1, got)
+		}
+	}
+}
+
+func TestGridStopsEarly(t *testing.T) {
+	g := newGrid(4*gridCellSize, 4*gridCellSize)
+	for id := 0; id < 3; id++ {
+		g.insert(id, 10, 10, 20, 20)
+	}
+	calls := 0
+	g.each(0, 0, 30, 30, func(id int) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("called %d times after asking to stop", calls)
+	}
+}
+
+func TestGridClear(t *testing.T) {
+	g := newGrid(4*gridCellSize, 4*gridCellSize)
+	g.insert(0, 10, 10, 20, 20)
+	g.clear()
+	if got := query(g, 0, 0, 4*gridCellSize, 4*gridCellSize); got != nil {
+		t.Errorf("reported %v after clearing", got)
+	}
+}
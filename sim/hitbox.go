@@ -0,0 +1,202 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// Shape is the geometric shape of a hitbox.
+type Shape string
+
+const (
+	ShapeBox    Shape = "box"
+	ShapeCircle Shape = "circle"
+)
+
+// Hitbox is the area of an entity that takes part in collisions, relative
+// to the top-left corner of the entity.
+type Hitbox struct {
+	Shape Shape   `json:"shape"`
+	X     float64 `json:"x"` // Top-left corner of a box, center of a circle
+	Y     float64 `json:"y"`
+	W     float64 `json:"w"` // Box size
+	H     float64 `json:"h"`
+	R     float64 `json:"r"` // Circle radius
+	// Mask names a mask registered with RegisterMask. When set, overlaps
+	// are confirmed pixel by pixel against the mask placed at the top-left
+	// corner of the entity.
+	Mask string `json:"mask"`
+}
+
+// BoxHitbox returns a box covering the given sprite bounds.
+func BoxHitbox(bounds image.Rectangle) Hitbox {
+	return Hitbox{Shape: ShapeBox, W: float64(bounds.Dx()), H: float64(bounds.Dy())}
+}
+
+// CircleHitbox returns a circle of radius r centered in the given sprite
+// bounds.
+func CircleHitbox(bounds image.Rectangle, r float64) Hitbox {
+	return Hitbox{Shape: ShapeCircle, X: float64(bounds.Dx()) / 2, Y: float64(bounds.Dy()) / 2, R: r}
+}
+
+// Validate reports whether the hitbox is usable.
+func (h Hitbox) Validate() error {
+	switch h.Shape {
+	case ShapeBox:
+		if h.W <= 0 || h.H <= 0 {
+			return errors.New("hitbox box needs a positive size")
+		}
+	case ShapeCircle:
+		if h.R <= 0 {
+			return errors.New("hitbox circle needs a positive radius")
+		}
+	default:
+		return fmt.Errorf("unknown hitbox shape %q", h.Shape)
+	}
+	return nil
+}
+
+// Hitboxes holds the hitboxes of the entities that are not defined per
+// type. The defaults match the bundled sprites; the frontend may replace
+// them with hitboxes derived from the sprites it loads.
+var Hitboxes = struct {
+	Player       Hitbox // Small core that enemies and bullets must hit
+	PlayerPickup Hitbox // Full body used for collecting power-ups
+	PlayerBullet Hitbox
+	EnemyBullet  Hitbox
+	Boss         Hitbox // Used when the boss config declares none
+	PowerUp      Hitbox
+}{
+	Player:       Hitbox{Shape: ShapeCircle, X: 17, Y: 17, R: 3},
+	PlayerPickup: Hitbox{Shape: ShapeBox, W: 34, H: 34},
+	PlayerBullet: Hitbox{Shape: ShapeBox, W: 4, H: 16},
+	EnemyBullet:  Hitbox{Shape: ShapeCircle, X: 6, Y: 6, R: 5},
+	Boss:         Hitbox{Shape: ShapeBox, W: 34, H: 34, Mask: "boss"},
+	PowerUp:      Hitbox{Shape: ShapeBox, W: 16, H: 16},
+}
+
+// Mask records which pixels of a sprite are opaque.
+type Mask struct {
+	w, h   int
+	opaque []bool
+}
+
+// NewMask builds the mask of img, treating pixels with at least half
+// opacity as solid.
+func NewMask(img image.Image) *Mask {
+	b := img.Bounds()
+	m := &Mask{w: b.Dx(), h: b.Dy(), opaque: make([]bool, b.Dx()*b.Dy())}
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			_, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			m.opaque[y*m.w+x] = a >= 0x8000
+		}
+	}
+	return m
+}
+
+func (m *Mask) at(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.w && y < m.h && m.opaque[y*m.w+x]
+}
+
+var masks = map[string]*Mask{}
+
+// RegisterMask makes m available to hitboxes under name.
+func RegisterMask(name string, m *Mask) {
+	masks[name] = m
+}
+
+// body is a hitbox placed in the world.
+type body struct {
+	Hitbox
+	x, y float64 // Top-left corner of the entity
+}
+
+func place(h Hitbox, x, y float64) body {
+	return body{h, x, y}
+}
+
+// bounds returns the bounding box of the shape.
+func (b body) bounds() (x0, y0, x1, y1 float64) {
+	if b.Shape == ShapeCircle {
+		cx, cy := b.x+b.X, b.y+b.Y
+		return cx - b.R, cy - b.R, cx + b.R, cy + b.R
+	}
+	return b.x + b.X, b.y + b.Y, b.x + b.X + b.W, b.y + b.Y + b.H
+}
+
+// contains reports whether the point lies within the shape.
+func (b body) contains(px, py float64) bool {
+	if b.Shape == ShapeCircle {
+		dx, dy := px-(b.x+b.X), py-(b.y+b.Y)
+		return dx*dx+dy*dy <= b.R*b.R
+	}
+	x0, y0, x1, y1 := b.bounds()
+	return px >= x0 && px < x1 && py >= y0 && py < y1
+}
+
+// overlaps reports whether two placed hitboxes collide.
+func overlaps(a, b body) bool {
+	if !shapesOverlap(a, b) {
+		return false
+	}
+	if m := masks[a.Mask]; m != nil {
+		return maskOverlaps(m, a, b)
+	}
+	if m := masks[b.Mask]; m != nil {
+		return maskOverlaps(m, b, a)
+	}
+	return true
+}
+
+func shapesOverlap(a, b body) bool {
+	switch {
+	case a.Shape == ShapeCircle && b.Shape == ShapeCircle:
+		dx, dy := (a.x+a.X)-(b.x+b.X), (a.y+a.Y)-(b.y+b.Y)
+		r := a.R + b.R
+		return dx*dx+dy*dy < r*r
+	case a.Shape == ShapeCircle:
+		return circleBoxOverlap(a, b)
+	case b.Shape == ShapeCircle:
+		return circleBoxOverlap(b, a)
+	}
+	ax0, ay0, ax1, ay1 := a.bounds()
+	bx0, by0, bx1, by1 := b.bounds()
+	return ax0 < bx1 && ax1 > bx0 && ay0 < by1 && ay1 > by0
+}
+
+func circleBoxOverlap(c, box body) bool {
+	x0, y0, x1, y1 := box.bounds()
+	cx, cy := c.x+c.X, c.y+c.Y
+	dx, dy := cx-clamp(cx, x0, x1), cy-clamp(cy, y0, y1)
+	return dx*dx+dy*dy < c.R*c.R
+}
+
+// maskOverlaps checks the opaque pixels of m, placed at the entity of a,
+// against the shape (and mask) of b.
+func maskOverlaps(m *Mask, a, b body) bool {
+	bx0, by0, bx1, by1 := b.bounds()
+	x0 := int(math.Max(0, math.Floor(bx0-a.x)))
+	y0 := int(math.Max(0, math.Floor(by0-a.y)))
+	x1 := int(math.Min(float64(m.w), math.Ceil(bx1-a.x)))
+	y1 := int(math.Min(float64(m.h), math.Ceil(by1-a.y)))
+	other := masks[b.Mask]
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if !m.at(x, y) {
+				continue
+			}
+			// Test the center of the pixel
+			px, py := a.x+float64(x)+0.5, a.y+float64(y)+0.5
+			if !b.contains(px, py) {
+				continue
+			}
+			if other == nil || other.at(int(px-b.x), int(py-b.y)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package sim
+
+// PlayerState is the stage of the player's life cycle.
+type PlayerState int
+
+const (
+	PlayerAlive        PlayerState = iota
+	PlayerExploding                // Hit, the aircraft blows up
+	PlayerRespawning               // The next aircraft flies in from the bottom
+	PlayerInvulnerable             // Back in control but can't be hit yet
+)
+
+const (
+	// DefaultInvulnerability is how many seconds the player can't be hit
+	// for after respawning unless configured otherwise.
+	DefaultInvulnerability = 2.0
+
+	explosionDuration = 1 * TicksPerSecond
+	respawnDuration   = 1 * TicksPerSecond
+
+	spawnY = ScreenHeight - 50
+)
+
+// Controllable reports whether the player is flying, as opposed to
+// exploding or respawning.
+func (p *Player) Controllable() bool {
+	return p.State == PlayerAlive || p.State == PlayerInvulnerable
+}
+
+// Blink reports whether a blinking player is hidden during the given frame.
+// The player blinks while respawning and while invulnerable.
+func (p *Player) Blink(frame int) bool {
+	return (p.State == PlayerRespawning || p.State == PlayerInvulnerable) && frame/4%2 == 0
+}
+
+// ExplosionProgress returns how far the explosion has gone, from 0 to 1.
+func (p *Player) ExplosionProgress() float64 {
+	if p.State != PlayerExploding {
+		return 0
+	}
+	return 1 - float64(p.StateTicks)/explosionDuration
+}
+
+func (p *Player) setState(state PlayerState, ticks int) {
+	p.State = state
+	p.StateTicks = ticks
+}
+
+// makeInvulnerable keeps the player from being hit for the given ticks.
+func (p *Player) makeInvulnerable(ticks int) {
+	switch p.State {
+	case PlayerAlive:
+		p.setState(PlayerInvulnerable, ticks)
+	case PlayerInvulnerable:
+		if ticks > p.StateTicks {
+			p.StateTicks = ticks
+		}
+	}
+}
+
+// updatePlayer advances the player through the states of its life cycle.
+func (w *World) updatePlayer() {
+	p := &w.Player
+	if p.State == PlayerAlive {
+		return
+	}
+	if p.StateTicks > 0 {
+		p.StateTicks--
+	}
+	switch p.State {
+	case PlayerExploding:
+		if p.StateTicks == 0 && w.Lives > 0 {
+			p.X = ScreenWidth / 2
+			p.Y = ScreenHeight
+			p.setState(PlayerRespawning, respawnDuration)
+		}
+	case PlayerRespawning:
+		// Fly in from below the screen up to the spawn point
+		p.Y = spawnY + float64(ScreenHeight-spawnY)*float64(p.StateTicks)/respawnDuration
+		if p.StateTicks == 0 {
+			p.setState(PlayerInvulnerable, seconds(w.Invulnerability))
+		}
+	case PlayerInvulnerable:
+		if p.StateTicks == 0 {
+			p.setState(PlayerAlive, 0)
+		}
+	}
+}
+
+// hitPlayer blows up the player, costing a life, unless the player is
+// invulnerable or the shield absorbs the hit. It reports whether the hit
+// got through.
+func (w *World) hitPlayer() bool {
+	if _, ok := w.effect(PowerUpShield); ok || w.Player.State != PlayerAlive {
+		return false
+	}
+	w.Lives--
+	w.Player.Bombs = BombStock
+	w.Player.Charge = 0
+	w.Player.setState(PlayerExploding, explosionDuration)
+	w.emit(EventPlayerHit, w.Player.X, w.Player.Y)
+	return true
+}
@@ -0,0 +1,51 @@
+package sim
+
+// Pool stores entities in a slice and reuses the slots of inactive ones so
+// the slice only grows as large as the most entities alive at once.
+// Inactive entries stay in Items until reused and must be skipped when
+// iterating.
+type Pool[T any] struct {
+	Items []T
+	free  []int // Indices of inactive items, refilled by sweep
+}
+
+// Add stores v in a free slot, or appends it if there is none, and
+// returns the index it was stored at.
+func (p *Pool[T]) Add(v T) int {
+	if n := len(p.free); n > 0 {
+		i := p.free[n-1]
+		p.Items[i] = v
+		p.free = p.free[:n-1]
+		return i
+	}
+	p.Items = append(p.Items, v)
+	return len(p.Items) - 1
+}
+
+// Reset removes every item, keeping the allocated memory.
+func (p *Pool[T]) Reset() {
+	p.Items = p.Items[:0]
+	p.free = p.free[:0]
+}
+
+// Len returns the number of active items as of the last sweep plus those
+// added since.
+func (p *Pool[T]) Len() int {
+	return len(p.Items) - len(p.free)
+}
+
+// sweep drops inactive items from the end of the slice and collects the
+// remaining inactive slots into the free list.
+func (p *Pool[T]) sweep(active func(*T) bool) {
+	n := len(p.Items)
+	for n > 0 && !active(&p.Items[n-1]) {
+		n--
+	}
+	p.Items = p.Items[:n]
+	p.free = p.free[:0]
+	for i := range p.Items {
+		if !active(&p.Items[i]) {
+			p.free = append(p.free, i)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+type item struct {
+	ID     int
+	Active bool
+}
+
+func sweepItems(p *Pool[item]) {
+	p.sweep(func(it *item) bool { return it.Active })
+}
+
+func TestPoolAppends(t *testing.T) {
+	var p Pool[item]
+	for i := 0; i < 3; i++ {
+		if got := p.Add(item{i, true}); got != i {
+			t.Errorf("added item %d at index %d", i, got)
+		}
+	}
+	if p.Len() != 3 {
+		t.Errorf("len = %d, want 3", p.Len())
+	}
+}
+
+func TestPoolReusesFreeSlots(t *testing.T) {
+	var p Pool[item]
+	for i := 0; i < 5; i++ {
+		p.Add(item{i, true})
+	}
+	p.Items[1].Active = false
+	p.Items[3].Active = false
+	sweepItems(&p)
+	if p.Len() != 3 {
+		t.Errorf("len = %d after the sweep, want 3", p.Len())
+	}
+
+	// Free slots are filled before the slice grows
+	got := []int{p.Add(item{5, true}), p.Add(item{6, true}), p.Add(item{7, true})}
+	if want := []int{3, 1, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("added at indices %v, want %v", got, want)
+	}
+	if len(p.Items) != 6 {
+		t.Errorf("%d items, want 6", len(p.Items))
+	}
+	if p.Items[1].ID != 6 || p.Items[3].ID != 5 {
+		t.Errorf("reused slots hold %d and %d, want 6 and 5", p.Items[1].ID, p.Items[3].ID)
+	}
+}
+
+func TestPoolSweepTrimsTail(t *testing.T) {
+	var p Pool[item]
+	for i := 0; i < 4; i++ {
+		p.Add(item{i, true})
+	}
+	p.Items[0].Active = false
+	p.Items[2].Active = false
+	p.Items[3].Active = false
+	sweepItems(&p)
+
+	if len(p.Items) != 2 {
+		t.Errorf("%d items after the sweep, want the 2 up to the last active one", len(p.Items))
+	}
+	if p.Len() != 1 {
+		t.Errorf("len = %d, want 1", p.Len())
+	}
+	if got := p.Add(item{4, true}); got != 0 {
+		t.Errorf("added at index %d, want the free slot 0", got)
+	}
+	if got := p.Add(item{5, true}); got != 2 {
+		t.Errorf("added at index %d, want 2 past the trimmed tail", got)
+	}
+}
+
+func TestPoolReset(t *testing.T) {
+	var p Pool[item]
+	p.Add(item{0, true})
+	p.Add(item{1, false})
+	sweepItems(&p)
+	p.Reset()
+	if p.Len() != 0 || len(p.Items) != 0 {
+		t.Errorf("len = %d with %d items after a reset", p.Len(), len(p.Items))
+	}
+	if got := p.Add(item{2, true}); got != 0 {
+		t.Errorf("added at index %d after a reset, want 0", got)
+	}
+}
@@ -0,0 +1,300 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// PowerUpKind is what a power-up grants when collected.
+type PowerUpKind string
+
+const (
+	PowerUpLife   PowerUpKind = "life"   // An extra life, up to Value lives
+	PowerUpWeapon PowerUpKind = "weapon" // A weapon upgrade level
+	PowerUpShield PowerUpKind = "shield" // Absorbs every hit while it lasts
+	PowerUpRapid  PowerUpKind = "rapid"  // Multiplies weapon cooldowns by Value
+	PowerUpBomb   PowerUpKind = "bomb"   // An extra bomb, up to Value bombs
+	PowerUpScore  PowerUpKind = "score"  // Multiplies scored points by Value
+	PowerUpSlow   PowerUpKind = "slow"   // Enemies only move on a Value fraction of the ticks
+	PowerUpMagnet PowerUpKind = "magnet" // Pulls power-ups within Radius in at Value pixels per tick
+)
+
+// PowerUpKinds lists every kind of power-up in the order the frontend shows
+// their effects.
+var PowerUpKinds = []PowerUpKind{
+	PowerUpLife, PowerUpWeapon, PowerUpShield, PowerUpRapid,
+	PowerUpBomb, PowerUpScore, PowerUpSlow, PowerUpMagnet,
+}
+
+// PowerUpType holds the parameters of a kind of power-up.
+type PowerUpType struct {
+	Duration float64 `json:"duration"` // Seconds the effect lasts, 0 for instant effects
+	Value    float64 `json:"value"`    // Strength of the effect, see the kinds
+	Radius   float64 `json:"radius"`
+	SpeedY   float64 `json:"speedY"` // How fast the power-up falls
+}
+
+// PowerUpTypes holds the parameters of every kind of power-up.
+var PowerUpTypes = map[PowerUpKind]*PowerUpType{
+	PowerUpLife:   {Value: 3, SpeedY: 1},
+	PowerUpWeapon: {SpeedY: 1},
+	PowerUpShield: {Duration: 8, SpeedY: 1},
+	PowerUpRapid:  {Duration: 10, Value: 0.5, SpeedY: 1},
+	PowerUpBomb:   {Value: 5, SpeedY: 1},
+	PowerUpScore:  {Duration: 15, Value: 2, SpeedY: 1},
+	PowerUpSlow:   {Duration: 6, Value: 0.5, SpeedY: 1},
+	PowerUpMagnet: {Duration: 12, Value: 4, Radius: 160, SpeedY: 1},
+}
+
+// Drop is one entry of a drop table.
+type Drop struct {
+	Kind   PowerUpKind `json:"kind"`
+	Chance float64     `json:"chance"` // Chance of dropping when the enemy is destroyed
+}
+
+// DefaultDrops is the drop table of enemy types without one of their own.
+const DefaultDrops = "default"
+
+// DropTables holds what destroyed enemies drop by enemy type. At most one
+// power-up drops, the first entry whose roll succeeds.
+var DropTables = map[string][]Drop{
+	DefaultDrops: {
+		{Kind: PowerUpWeapon, Chance: 0.05},
+		{Kind: PowerUpRapid, Chance: 0.01},
+		{Kind: PowerUpScore, Chance: 0.01},
+		{Kind: PowerUpMagnet, Chance: 0.01},
+	},
+	"turret": {
+		{Kind: PowerUpWeapon, Chance: 0.1},
+		{Kind: PowerUpShield, Chance: 0.05},
+		{Kind: PowerUpSlow, Chance: 0.05},
+	},
+	"bomber": {
+		{Kind: PowerUpWeapon, Chance: 0.1},
+		{Kind: PowerUpBomb, Chance: 0.1},
+		{Kind: PowerUpShield, Chance: 0.05},
+	},
+}
+
+// ValidatePowerUps reports every problem with the power-up parameters types
+// and drop tables drops, which are meant to replace PowerUpTypes and
+// DropTables.
+func ValidatePowerUps(types map[PowerUpKind]*PowerUpType, drops map[string][]Drop) error {
+	var errs []error
+	for kind := range types {
+		if !knownPowerUp(kind) {
+			errs = append(errs, fmt.Errorf("power-up %q: unknown kind", kind))
+		}
+	}
+	for _, kind := range PowerUpKinds {
+		t, ok := types[kind]
+		if !ok {
+			errs = append(errs, fmt.Errorf("power-up %q: missing", kind))
+			continue
+		}
+		if t == nil {
+			errs = append(errs, fmt.Errorf("power-up %q: no parameters", kind))
+			continue
+		}
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("power-up %q: %s", kind, fmt.Sprintf(format, args...)))
+		}
+		if t.Duration < 0 || t.Value < 0 || t.Radius < 0 {
+			fail("parameters must not be negative")
+		}
+		if t.SpeedY <= 0 {
+			fail("speedY must be positive")
+		}
+		if timed(kind) && seconds(t.Duration) <= 0 {
+			fail("duration must be at least one tick")
+		}
+		switch kind {
+		case PowerUpLife, PowerUpBomb:
+			if t.Value < 1 {
+				fail("value must be at least 1")
+			}
+		case PowerUpRapid, PowerUpSlow:
+			if t.Value <= 0 || t.Value >= 1 {
+				fail("value must be between 0 and 1")
+			}
+		case PowerUpScore:
+			if t.Value < 1 {
+				fail("value must be at least 1")
+			}
+		case PowerUpMagnet:
+			if t.Value <= 0 {
+				fail("value must be positive")
+			}
+		}
+		if kind == PowerUpMagnet && t.Radius <= 0 {
+			fail("radius must be positive")
+		}
+	}
+	for enemy, table := range drops {
+		if _, ok := EnemyTypes[enemy]; !ok && enemy != DefaultDrops {
+			errs = append(errs, fmt.Errorf("drops of %q: unknown enemy", enemy))
+		}
+		for _, d := range table {
+			if !knownPowerUp(d.Kind) {
+				errs = append(errs, fmt.Errorf("drops of %q: unknown power-up %q", enemy, d.Kind))
+			}
+			if d.Chance < 0 || d.Chance > 1 {
+				errs = append(errs, fmt.Errorf("drops of %q: chance of %q must be between 0 and 1", enemy, d.Kind))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// timed reports whether the power-up grants an effect lasting Duration
+// seconds rather than an instant one.
+func timed(kind PowerUpKind) bool {
+	switch kind {
+	case PowerUpLife, PowerUpWeapon, PowerUpBomb:
+		return false
+	}
+	return true
+}
+
+func knownPowerUp(kind PowerUpKind) bool {
+	for _, k := range PowerUpKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+type PowerUp struct {
+	Kind   PowerUpKind
+	X, Y   float64
+	Active bool
+	SpeedY float64
+}
+
+// lifeRespawnTime is how often an extra life appears.
+const lifeRespawnTime = 30 * TicksPerSecond
+
+// dropFrom lets a destroyed enemy drop a power-up.
+func (w *World) dropFrom(e *Enemy) {
+	table, ok := DropTables[e.Type]
+	if !ok {
+		table = DropTables[DefaultDrops]
+	}
+	for _, d := range table {
+		if w.rng.Float64() < d.Chance {
+			w.spawnPowerUp(d.Kind, e.X, e.Y)
+			return
+		}
+	}
+}
+
+func (w *World) spawnPowerUp(kind PowerUpKind, x, y float64) {
+	w.PowerUps.Add(PowerUp{Kind: kind, X: x, Y: y, SpeedY: PowerUpTypes[kind].SpeedY, Active: true})
+}
+
+// effect returns the parameters of an effect if it is active.
+func (w *World) effect(kind PowerUpKind) (*PowerUpType, bool) {
+	if w.Effects[kind] == 0 {
+		return nil, false
+	}
+	return PowerUpTypes[kind], true
+}
+
+// EffectTime returns the seconds an effect still lasts, 0 if it is not
+// active.
+func (w *World) EffectTime(kind PowerUpKind) float64 {
+	return float64(w.Effects[kind]) / TicksPerSecond
+}
+
+func (w *World) updatePowerUps() {
+	// Let timed effects run out
+	for kind, ticks := range w.Effects {
+		if ticks <= 1 {
+			delete(w.Effects, kind)
+		} else {
+			w.Effects[kind] = ticks - 1
+		}
+	}
+
+	w.powerUpCounter++
+	if w.powerUpCounter >= lifeRespawnTime {
+		// Spawn an extra life randomly on the map
+		w.spawnPowerUp(PowerUpLife, w.rng.Float64()*ScreenWidth, w.rng.Float64()*ScreenHeight)
+		w.powerUpCounter = 0 // Reset the timer
+	}
+
+	magnet, pulling := w.effect(PowerUpMagnet)
+	pickup := place(Hitboxes.PlayerPickup, w.Player.X, w.Player.Y)
+	collecting := w.Player.Controllable()
+	for i := range w.PowerUps.Items {
+		p := &w.PowerUps.Items[i]
+		if !p.Active {
+			continue
+		}
+		// Update power-up position for movement
+		p.Y += p.SpeedY
+		if pulling {
+			dx, dy := w.Player.X-p.X, w.Player.Y-p.Y
+			if d := math.Hypot(dx, dy); d > 0 && d < magnet.Radius {
+				step := math.Min(magnet.Value, d)
+				p.X += dx / d * step
+				p.Y += dy / d * step
+			}
+		}
+
+		// Check for collision with power-up
+		if p.Y > (ScreenHeight - entitySize) {
+			p.Active = false
+		} else if collecting && overlaps(pickup, place(Hitboxes.PowerUp, p.X, p.Y)) {
+			p.Active = false // Deactivate the power-up after collecting
+			w.collect(p.Kind)
+		}
+	}
+}
+
+func (w *World) collect(kind PowerUpKind) {
+	t := PowerUpTypes[kind]
+	switch kind {
+	case PowerUpLife:
+		if w.Lives < int(t.Value) {
+			w.Lives++
+		}
+	case PowerUpWeapon:
+		if w.Player.WeaponLevel < MaxWeaponLevel {
+			w.Player.WeaponLevel++
+		}
+	case PowerUpBomb:
+		if w.Player.Bombs < int(t.Value) {
+			w.Player.Bombs++
+		}
+	default:
+		// Collecting a timed effect again restarts it
+		w.Effects[kind] = seconds(t.Duration)
+	}
+}
+
+// addScore awards points, applying the score multiplier.
+func (w *World) addScore(points int) {
+	if t, ok := w.effect(PowerUpScore); ok {
+		points = int(float64(points) * t.Value)
+	}
+	w.Score += points
+}
+
+// updateSlowTime reports whether enemies sit out this tick because time is
+// slowed down.
+func (w *World) updateSlowTime() bool {
+	t, ok := w.effect(PowerUpSlow)
+	if !ok {
+		w.slowClock = 0
+		return false
+	}
+	w.slowClock += t.Value
+	if w.slowClock < 1 {
+		return true
+	}
+	w.slowClock--
+	return false
+}
@@ -0,0 +1,39 @@
+package sim
+
+import "testing"
+
+func TestValidatePowerUps(t *testing.T) {
+	tests := []struct {
+		name string
+		kind PowerUpKind
+		t    *PowerUpType
+	}{
+		{"no parameters", PowerUpShield, nil},
+		{"negative value", PowerUpWeapon, &PowerUpType{Value: -1, SpeedY: 1}},
+		{"not falling", PowerUpWeapon, &PowerUpType{}},
+		{"shield without duration", PowerUpShield, &PowerUpType{SpeedY: 1}},
+		{"duration under a tick", PowerUpScore, &PowerUpType{Duration: 0.001, Value: 2, SpeedY: 1}},
+		{"rapid without value", PowerUpRapid, &PowerUpType{Duration: 10, SpeedY: 1}},
+		{"rapid not shortening cooldowns", PowerUpRapid, &PowerUpType{Duration: 10, Value: 1, SpeedY: 1}},
+		{"slow without value", PowerUpSlow, &PowerUpType{Duration: 6, SpeedY: 1}},
+		{"slow over every tick", PowerUpSlow, &PowerUpType{Duration: 6, Value: 1.5, SpeedY: 1}},
+		{"score lowering points", PowerUpScore, &PowerUpType{Duration: 15, Value: 0.5, SpeedY: 1}},
+		{"life without value", PowerUpLife, &PowerUpType{SpeedY: 1}},
+		{"magnet without radius", PowerUpMagnet, &PowerUpType{Duration: 12, Value: 4, SpeedY: 1}},
+	}
+	if err := ValidatePowerUps(PowerUpTypes, DropTables); err != nil {
+		t.Fatalf("built-in power-ups: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			types := make(map[PowerUpKind]*PowerUpType)
+			for kind, t := range PowerUpTypes {
+				types[kind] = t
+			}
+			types[tt.kind] = tt.t
+			if err := ValidatePowerUps(types, DropTables); err == nil {
+				t.Errorf("accepted %+v for %q", tt.t, tt.kind)
+			}
+		})
+	}
+}
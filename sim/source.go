@@ -0,0 +1,45 @@
+package sim
+
+import "math/rand"
+
+// Source is a random source whose state can be saved and restored. It
+// draws the same numbers as math/rand's source for the same seed and counts
+// the draws, so its state is the seed and the number of draws.
+type Source struct {
+	src   rand.Source64
+	seed  int64
+	draws uint64
+}
+
+func NewSource(seed int64) *Source {
+	return &Source{src: rand.NewSource(seed).(rand.Source64), seed: seed}
+}
+
+func (s *Source) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *Source) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *Source) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed, s.draws = seed, 0
+}
+
+// State returns the seed and the number of draws since seeding.
+func (s *Source) State() (seed int64, draws uint64) {
+	return s.seed, s.draws
+}
+
+// Restore puts the source back into the state returned by State, replaying
+// the draws.
+func (s *Source) Restore(seed int64, draws uint64) {
+	s.Seed(seed)
+	for ; draws > 0; draws-- {
+		s.Uint64()
+	}
+}
@@ -0,0 +1,89 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+)
+
+// scriptedInput is the input of a scripted run at the given tick: weaving
+// left and right while firing and bombing now and then.
+func scriptedInput(tick int) Input {
+	return Input{
+		Left:  tick%90 < 30,
+		Right: tick%90 >= 60,
+		Fire:  true,
+		Bomb:  tick%700 == 0,
+	}
+}
+
+// spawn is an enemy as it entered the world.
+type spawn struct {
+	Tick int
+	Type string
+	X, Y float64
+}
+
+// playSeeded runs a world seeded with seed for the given ticks of scripted
+// input and returns the enemies in the order they spawned, along with the
+// world.
+func playSeeded(t *testing.T, seed int64, ticks int) ([]spawn, *World) {
+	t.Helper()
+	w := NewWorld(NewSource(seed))
+	w.Invulnerability = 1000 // Keep playing to the end
+	var spawns []spawn
+	seen := map[int]bool{}
+	for tick := 0; tick < ticks; tick++ {
+		w.Step(scriptedInput(tick), tickDuration)
+		for i := range w.Enemies.Items {
+			e := &w.Enemies.Items[i]
+			// A slot reused by a new enemy starts over at age 0
+			if e.Active && e.Age <= 1 && !seen[i] {
+				spawns = append(spawns, spawn{tick, e.Type, e.BaseX, e.Y})
+			}
+			seen[i] = e.Active && e.Age > 1
+		}
+	}
+	return spawns, w
+}
+
+func TestSameSeedSameRun(t *testing.T) {
+	const ticks = 60 * TicksPerSecond
+	spawnsA, a := playSeeded(t, 42, ticks)
+	spawnsB, b := playSeeded(t, 42, ticks)
+
+	if len(spawnsA) == 0 {
+		t.Fatal("no enemies spawned")
+	}
+	if !reflect.DeepEqual(spawnsA, spawnsB) {
+		t.Errorf("the same seed spawned different enemies")
+	}
+	stateA, err := a.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateB, err := b.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stateA, stateB) {
+		t.Errorf("the same seed and input led to different worlds")
+	}
+}
+
+func TestOtherSeedOtherRun(t *testing.T) {
+	spawnsA, _ := playSeeded(t, 1, 20*TicksPerSecond)
+	spawnsB, _ := playSeeded(t, 2, 20*TicksPerSecond)
+	if reflect.DeepEqual(spawnsA, spawnsB) {
+		t.Errorf("different seeds spawned the same enemies")
+	}
+}
+
+func TestReseedStartsOver(t *testing.T) {
+	w := NewWorld(NewSource(7))
+	first := w.rng.Int63()
+	w.rng.Int63()
+	w.Reseed(7)
+	if got := w.rng.Int63(); got != first {
+		t.Errorf("first draw after reseeding = %d, want %d", got, first)
+	}
+}
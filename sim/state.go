@@ -0,0 +1,299 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxDraws is the most draws from the random source a state may count.
+// Restoring a state replays every draw, so a corrupt count would hang the
+// load; runs draw a few hundred numbers a second and stay far below.
+const MaxDraws = 1 << 28
+
+// State is everything a world needs to carry on exactly where it left off,
+// in a form that can be serialized.
+type State struct {
+	Seed  int64  `json:"seed"`  // Seed of the random source
+	Draws uint64 `json:"draws"` // Numbers drawn from the random source since seeding
+
+	Player          Player                  `json:"player"`
+	Enemies         PoolState[Enemy]        `json:"enemies"`
+	PlayerBullets   PoolState[PlayerBullet] `json:"playerBullets"`
+	EnemyBullets    PoolState[EnemyBullet]  `json:"enemyBullets"`
+	Boss            Boss                    `json:"boss"`
+	BossActive      bool                    `json:"bossActive"`
+	PowerUps        PoolState[PowerUp]      `json:"powerUps"`
+	Score           int                     `json:"score"`
+	RunScore        int                     `json:"runScore"`
+	Lives           int                     `json:"lives"`
+	Frame           int                     `json:"frame"`
+	BgOffsetY       float64                 `json:"bgOffsetY"`
+	Spawner         SpawnerState            `json:"spawner"`
+	Effects         map[PowerUpKind]int     `json:"effects"`
+	Invulnerability float64                 `json:"invulnerability"`
+
+	PowerUpCounter int     `json:"powerUpCounter"`
+	EnemyIDs       int     `json:"enemyIDs"`
+	SlowClock      float64 `json:"slowClock"`
+	BombHeld       bool    `json:"bombHeld"`
+	Accumulator    float64 `json:"accumulator"`
+}
+
+// PoolState is the contents of a pool, including which slots are reused
+// next.
+type PoolState[T any] struct {
+	Items []T   `json:"items"`
+	Free  []int `json:"free"`
+}
+
+func poolState[T any](p *Pool[T]) PoolState[T] {
+	return PoolState[T]{Items: append([]T(nil), p.Items...), Free: append([]int(nil), p.free...)}
+}
+
+// check reports the first free slot out of range, listed twice or holding
+// an item that active reports as in use, or the first item that item, if
+// not nil, finds a problem with.
+func (s PoolState[T]) check(active func(*T) bool, item func(*T) error) error {
+	free := make(map[int]bool, len(s.Free))
+	for _, i := range s.Free {
+		switch {
+		case i < 0 || i >= len(s.Items):
+			return fmt.Errorf("free slot %d out of range", i)
+		case free[i]:
+			return fmt.Errorf("free slot %d listed twice", i)
+		case active(&s.Items[i]):
+			return fmt.Errorf("free slot %d holds an active item", i)
+		}
+		free[i] = true
+	}
+	for i := range s.Items {
+		if item == nil {
+			break
+		}
+		if err := item(&s.Items[i]); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func checkEnemy(e *Enemy) error {
+	if _, ok := EnemyTypes[e.Type]; !ok {
+		return fmt.Errorf("unknown enemy type %q", e.Type)
+	}
+	return checkCounters(
+		counter{"ID", e.ID},
+		counter{"age", e.Age},
+		counter{"held ticks", e.Held},
+		counter{"shots", e.Shots},
+		counter{"flash", e.Flash},
+	)
+}
+
+func checkPowerUp(p *PowerUp) error {
+	if !knownPowerUp(p.Kind) {
+		return fmt.Errorf("unknown power-up %q", p.Kind)
+	}
+	return nil
+}
+
+// counter is a named count or timer of a state, which can't be negative.
+type counter struct {
+	name string
+	n    int
+}
+
+// checkCounters reports the first of counters that is negative.
+func checkCounters(counters ...counter) error {
+	for _, c := range counters {
+		if c.n < 0 {
+			return fmt.Errorf("negative %s %d", c.name, c.n)
+		}
+	}
+	return nil
+}
+
+func (s PoolState[T]) restore(p *Pool[T]) {
+	p.Items = append(p.Items[:0], s.Items...)
+	p.free = append(p.free[:0], s.Free...)
+}
+
+// SpawnerState is the state of the spawner; exactly one of its fields is
+// set.
+type SpawnerState struct {
+	Escalation *EscalationState `json:"escalation,omitempty"`
+	Waves      *WaveScriptState `json:"waves,omitempty"`
+}
+
+type EscalationState struct {
+	Tick int `json:"tick"`
+	Next int `json:"next"`
+}
+
+type WaveScriptState struct {
+	Waves []Wave `json:"waves"`
+	Wave  int    `json:"wave"`
+	Tick  int    `json:"tick"`
+}
+
+// State returns a copy of the state of the world. It fails if the spawner
+// is not one of the package's.
+func (w *World) State() (State, error) {
+	s := State{
+		Player:          w.Player,
+		Enemies:         poolState(&w.Enemies),
+		PlayerBullets:   poolState(&w.PlayerBullets),
+		EnemyBullets:    poolState(&w.EnemyBullets),
+		Boss:            w.Boss,
+		BossActive:      w.BossActive,
+		PowerUps:        poolState(&w.PowerUps),
+		Score:           w.Score,
+		RunScore:        w.RunScore,
+		Lives:           w.Lives,
+		Frame:           w.Frame,
+		BgOffsetY:       w.BgOffsetY,
+		Effects:         map[PowerUpKind]int{},
+		Invulnerability: w.Invulnerability,
+		PowerUpCounter:  w.powerUpCounter,
+		EnemyIDs:        w.enemyIDs,
+		SlowClock:       w.slowClock,
+		BombHeld:        w.bombHeld,
+		Accumulator:     w.accumulator,
+	}
+	s.Seed, s.Draws = w.src.State()
+	for kind, ticks := range w.Effects {
+		s.Effects[kind] = ticks
+	}
+	switch sp := w.Spawner.(type) {
+	case *Escalation:
+		s.Spawner.Escalation = &EscalationState{Tick: sp.tick, Next: sp.next}
+	case *WaveScript:
+		s.Spawner.Waves = &WaveScriptState{Waves: sp.waves, Wave: sp.wave, Tick: sp.tick}
+	default:
+		return s, fmt.Errorf("sim: can't save a spawner of type %T", w.Spawner)
+	}
+	return s, nil
+}
+
+// check reports the first problem that would keep a world from carrying
+// on from s. States come from files, so nothing in them can be trusted.
+func (s State) check() error {
+	if s.Draws > MaxDraws {
+		return fmt.Errorf("sim: %d draws from the random source, more than %d", s.Draws, MaxDraws)
+	}
+	if s.Accumulator < -timestepDrift || s.Accumulator >= tickDuration {
+		return fmt.Errorf("sim: accumulated time %v out of range", s.Accumulator)
+	}
+	if s.Invulnerability < 0 || s.SlowClock < 0 {
+		return errors.New("sim: negative invulnerability or slow clock")
+	}
+	player := s.Player
+	if player.WeaponLevel < 1 || player.WeaponLevel > MaxWeaponLevel {
+		return fmt.Errorf("sim: weapon level %d out of range", player.WeaponLevel)
+	}
+	err := checkCounters(
+		counter{"lives", s.Lives},
+		counter{"frame", s.Frame},
+		counter{"power-up counter", s.PowerUpCounter},
+		counter{"enemy IDs", s.EnemyIDs},
+		counter{"player state ticks", player.StateTicks},
+		counter{"primary cooldown", player.PrimaryCooldown},
+		counter{"secondary cooldown", player.SecondaryCooldown},
+		counter{"charge", player.Charge},
+		counter{"bombs", player.Bombs},
+	)
+	if err != nil {
+		return fmt.Errorf("sim: %w", err)
+	}
+	switch {
+	case s.Spawner.Escalation != nil:
+		es := s.Spawner.Escalation
+		if err := checkCounters(counter{"spawner tick", es.Tick}, counter{"next spawn", es.Next}); err != nil {
+			return fmt.Errorf("sim: %w", err)
+		}
+	case s.Spawner.Waves != nil:
+		ws := s.Spawner.Waves
+		if err := checkCounters(counter{"spawner tick", ws.Tick}); err != nil {
+			return fmt.Errorf("sim: %w", err)
+		}
+		if len(ws.Waves) > 0 && (ws.Wave < 0 || ws.Wave >= len(ws.Waves)) {
+			return fmt.Errorf("sim: wave %d out of range", ws.Wave)
+		}
+		for i, wv := range ws.Waves {
+			if err := wv.Validate(); err != nil {
+				return fmt.Errorf("sim: wave %d: %w", i+1, err)
+			}
+		}
+	default:
+		return errors.New("sim: the state has no spawner")
+	}
+	if s.BossActive {
+		if err := s.Boss.check(); err != nil {
+			return fmt.Errorf("sim: boss: %w", err)
+		}
+	}
+	pools := []struct {
+		name string
+		err  error
+	}{
+		{"enemies", s.Enemies.check(func(e *Enemy) bool { return e.Active }, checkEnemy)},
+		{"player bullets", s.PlayerBullets.check(func(b *PlayerBullet) bool { return b.Active }, nil)},
+		{"enemy bullets", s.EnemyBullets.check(func(b *EnemyBullet) bool { return b.Active }, nil)},
+		{"power-ups", s.PowerUps.check(func(p *PowerUp) bool { return p.Active }, checkPowerUp)},
+	}
+	for _, p := range pools {
+		if p.err != nil {
+			return fmt.Errorf("sim: %s: %w", p.name, p.err)
+		}
+	}
+	for kind, ticks := range s.Effects {
+		if !knownPowerUp(kind) {
+			return fmt.Errorf("sim: effect of unknown power-up %q", kind)
+		}
+		if ticks < 0 {
+			return fmt.Errorf("sim: negative ticks %d of effect %q", ticks, kind)
+		}
+	}
+	return nil
+}
+
+// SetState puts the world into the state s, as returned by State. The world
+// is left alone if s is invalid.
+func (w *World) SetState(s State) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	var spawner Spawner
+	if es := s.Spawner.Escalation; es != nil {
+		spawner = &Escalation{tick: es.Tick, next: es.Next}
+	} else {
+		ws := s.Spawner.Waves
+		spawner = &WaveScript{waves: ws.Waves, wave: ws.Wave, tick: ws.Tick}
+	}
+
+	w.src.Restore(s.Seed, s.Draws)
+	s.Enemies.restore(&w.Enemies)
+	s.PlayerBullets.restore(&w.PlayerBullets)
+	s.EnemyBullets.restore(&w.EnemyBullets)
+	s.PowerUps.restore(&w.PowerUps)
+	w.Player = s.Player
+	w.Boss = s.Boss
+	w.BossActive = s.BossActive
+	w.Score = s.Score
+	w.RunScore = s.RunScore
+	w.Lives = s.Lives
+	w.Frame = s.Frame
+	w.BgOffsetY = s.BgOffsetY
+	w.Spawner = spawner
+	w.Effects = map[PowerUpKind]int{}
+	for kind, ticks := range s.Effects {
+		w.Effects[kind] = ticks
+	}
+	w.Invulnerability = s.Invulnerability
+	w.powerUpCounter = s.PowerUpCounter
+	w.enemyIDs = s.EnemyIDs
+	w.slowClock = s.SlowClock
+	w.bombHeld = s.BombHeld
+	w.accumulator = s.Accumulator
+	return nil
+}
@@ -0,0 +1,188 @@
+package sim
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testBoss = BossConfig{
+	Name:   "boss.test",
+	Health: 1000,
+	Phases: []BossPhase{
+		{Below: 1, Speed: 2, Attacks: []Attack{
+			{Pattern: AttackRadial, Telegraph: 0.25, Cooldown: 0.5, Bullets: 8, BulletSpeed: 2},
+			{Pattern: AttackSpiral, Telegraph: 0.25, Duration: 1, Cooldown: 0.5, Rate: 8, Bullets: 3, Spin: 10, BulletSpeed: 2},
+		}},
+	},
+}
+
+var testWaves = []Wave{
+	{Duration: 3, Groups: []Group{
+		{Enemy: "drone", Count: 3, Formation: FormationLine, Spacing: 50, MinSpeed: 1, MaxSpeed: 2},
+		{Delay: 1, Enemy: "turret", Count: 2, Formation: FormationV, Spacing: 60, MinSpeed: 1, MaxSpeed: 1},
+	}},
+}
+
+// playedWorlds returns worlds in the middle of a Competition run, of a
+// Story level and of a boss fight.
+func playedWorlds(t *testing.T) map[string]*World {
+	t.Helper()
+	competition := NewWorld(NewSource(5))
+	competition.Invulnerability = 1000
+	story := NewWorld(NewSource(6))
+	story.Invulnerability = 1000
+	story.ResetLevel(testWaves)
+	boss := NewWorld(NewSource(7))
+	boss.Invulnerability = 1000
+	boss.ResetLevel(testWaves)
+	boss.SpawnBoss(testBoss)
+
+	worlds := map[string]*World{"competition": competition, "story": story, "boss": boss}
+	for _, w := range worlds {
+		for tick := 0; tick < 20*TicksPerSecond; tick++ {
+			w.Step(scriptedInput(tick), tickDuration)
+		}
+	}
+	return worlds
+}
+
+// roundTrip returns s after encoding it to JSON and decoding it again, as
+// snapshot files do.
+func roundTrip(t *testing.T, s State) State {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded State
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	for name, w := range playedWorlds(t) {
+		t.Run(name, func(t *testing.T) {
+			s, err := w.State()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.Enemies.Len()+w.EnemyBullets.Len() == 0 {
+				t.Fatal("nothing to save")
+			}
+			restored := NewWorld(NewSource(0))
+			if err := restored.SetState(roundTrip(t, s)); err != nil {
+				t.Fatal(err)
+			}
+			got, err := restored.State()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, s) {
+				t.Fatalf("restored a different state")
+			}
+
+			// Both worlds carry on the same way
+			for tick := 0; tick < 10*TicksPerSecond; tick++ {
+				in := scriptedInput(tick)
+				w.Step(in, tickDuration)
+				restored.Step(in, tickDuration)
+			}
+			want, _ := w.State()
+			got, _ = restored.State()
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("the restored world played out differently")
+			}
+		})
+	}
+}
+
+func TestSetStateInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		world  string
+		modify func(s *State)
+	}{
+		{"too many draws", "competition", func(s *State) { s.Draws = MaxDraws + 1 }},
+		{"no spawner", "competition", func(s *State) { s.Spawner = SpawnerState{} }},
+		{"wave out of range", "story", func(s *State) { s.Spawner.Waves.Wave = 1 }},
+		{"invalid wave", "story", func(s *State) {
+			s.Spawner.Waves.Waves = []Wave{{Duration: 1, Groups: []Group{{Enemy: "ufo", Count: 1, Formation: FormationLine, MinSpeed: 1, MaxSpeed: 1}}}}
+		}},
+		{"unknown enemy type", "story", func(s *State) { s.Enemies.Items[0].Type = "ufo" }},
+		{"free slot out of range", "story", func(s *State) { s.Enemies.Free = append(s.Enemies.Free, len(s.Enemies.Items)) }},
+		{"free slot listed twice", "story", func(s *State) {
+			s.Enemies.Items = append(s.Enemies.Items, Enemy{Type: "drone"})
+			n := len(s.Enemies.Items) - 1
+			s.Enemies.Free = append(s.Enemies.Free, n, n)
+		}},
+		{"free slot in use", "story", func(s *State) {
+			s.PowerUps.Items = append(s.PowerUps.Items, PowerUp{Kind: PowerUpBomb, Active: true})
+			s.PowerUps.Free = append(s.PowerUps.Free, len(s.PowerUps.Items)-1)
+		}},
+		{"negative enemy timer", "story", func(s *State) {
+			s.Enemies.Items = append(s.Enemies.Items, Enemy{Type: "drone", Flash: -1, Active: true})
+		}},
+		{"negative enemy ID", "story", func(s *State) {
+			s.Enemies.Items = append(s.Enemies.Items, Enemy{ID: -1, Type: "drone", Active: true})
+		}},
+		{"accumulator below zero", "competition", func(s *State) { s.Accumulator = -0.01 }},
+		{"accumulator of a tick", "competition", func(s *State) { s.Accumulator = tickDuration }},
+		{"negative lives", "competition", func(s *State) { s.Lives = -1 }},
+		{"negative invulnerability", "competition", func(s *State) { s.Invulnerability = -1 }},
+		{"negative slow clock", "competition", func(s *State) { s.SlowClock = -1 }},
+		{"negative frame", "competition", func(s *State) { s.Frame = -1 }},
+		{"negative power-up counter", "competition", func(s *State) { s.PowerUpCounter = -1 }},
+		{"negative enemy IDs", "competition", func(s *State) { s.EnemyIDs = -1 }},
+		{"negative effect", "competition", func(s *State) { s.Effects[PowerUpShield] = -1 }},
+		{"negative player state ticks", "competition", func(s *State) { s.Player.StateTicks = -1 }},
+		{"negative cooldown", "competition", func(s *State) { s.Player.PrimaryCooldown = -1 }},
+		{"negative secondary cooldown", "competition", func(s *State) { s.Player.SecondaryCooldown = -1 }},
+		{"negative charge", "competition", func(s *State) { s.Player.Charge = -1 }},
+		{"negative bombs", "competition", func(s *State) { s.Player.Bombs = -1 }},
+		{"weapon level 0", "competition", func(s *State) { s.Player.WeaponLevel = 0 }},
+		{"weapon level over the maximum", "competition", func(s *State) { s.Player.WeaponLevel = MaxWeaponLevel + 1 }},
+		{"negative escalation tick", "competition", func(s *State) { s.Spawner.Escalation.Tick = -1 }},
+		{"negative next spawn", "competition", func(s *State) { s.Spawner.Escalation.Next = -1 }},
+		{"negative wave tick", "story", func(s *State) { s.Spawner.Waves.Tick = -1 }},
+		{"unknown power-up", "story", func(s *State) {
+			s.PowerUps.Items = append(s.PowerUps.Items, PowerUp{Kind: "coin", Active: true})
+		}},
+		{"unknown effect", "story", func(s *State) { s.Effects["coin"] = 10 }},
+		{"boss without phases", "boss", func(s *State) { s.Boss.Config.Phases = nil }},
+		{"boss phase out of range", "boss", func(s *State) { s.Boss.Phase = 1 }},
+		{"negative boss phase", "boss", func(s *State) { s.Boss.Phase = -1 }},
+		{"boss attack out of range", "boss", func(s *State) { s.Boss.Attack = 2 }},
+		{"unknown boss stage", "boss", func(s *State) { s.Boss.Stage = 3 }},
+		{"negative boss stage ticks", "boss", func(s *State) { s.Boss.StageTicks = -1 }},
+		{"negative boss flash", "boss", func(s *State) { s.Boss.Flash = -1 }},
+		{"invalid boss attack", "boss", func(s *State) {
+			s.Boss.Config.Phases[0].Attacks = []Attack{{Pattern: "wave"}}
+		}},
+	}
+	worlds := playedWorlds(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := worlds[tt.world]
+			before, err := w.State()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := roundTrip(t, before)
+			tt.modify(&s)
+			start := time.Now()
+			if err := w.SetState(s); err == nil {
+				t.Fatalf("accepted the state")
+			}
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("took %v to reject", d)
+			}
+			if after, _ := w.State(); !reflect.DeepEqual(after, before) {
+				t.Errorf("changed the world")
+			}
+		})
+	}
+}
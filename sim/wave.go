@@ -0,0 +1,226 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Formation is the shape a group of enemies enters the screen in.
+type Formation string
+
+const (
+	FormationLine   Formation = "line"   // Side by side
+	FormationColumn Formation = "column" // One behind the other
+	FormationV      Formation = "v"      // Leader in front, wings trailing behind
+	FormationCircle Formation = "circle"
+)
+
+var formations = []Formation{FormationLine, FormationColumn, FormationV, FormationCircle}
+
+// DefaultEnemy is the enemy type spawned when a group names none.
+const DefaultEnemy = "fighter"
+
+// Group is a set of enemies of one type spawned together in a formation.
+type Group struct {
+	Delay     float64   `json:"delay"` // Seconds after the start of the wave
+	Enemy     string    `json:"enemy"`
+	Count     int       `json:"count"`
+	Formation Formation `json:"formation"`
+	X         *float64  `json:"x"`       // Horizontal spawn point of the formation center; random when omitted
+	Y         float64   `json:"y"`       // Vertical spawn point of the formation leader
+	Spacing   float64   `json:"spacing"` // Distance between neighbouring enemies
+	MinSpeed  float64   `json:"minSpeed"`
+	MaxSpeed  float64   `json:"maxSpeed"`
+}
+
+// Wave is a timed set of groups. The next wave starts once Duration seconds
+// have passed.
+type Wave struct {
+	Duration float64 `json:"duration"`
+	Groups   []Group `json:"groups"`
+}
+
+// Validate reports every problem found in the wave.
+func (wv Wave) Validate() error {
+	var errs []error
+	// The script counts whole ticks, so check the rounded times
+	if seconds(wv.Duration) <= 0 {
+		errs = append(errs, errors.New("wave duration must be at least one tick"))
+	}
+	for i, g := range wv.Groups {
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("group %d: %s", i+1, fmt.Sprintf(format, args...)))
+		}
+		if g.Delay < 0 || seconds(g.Delay) >= seconds(wv.Duration) {
+			fail("delay must end at least one tick before the wave does")
+		}
+		if !knownEnemy(g.Enemy) {
+			fail("unknown enemy type %q", g.Enemy)
+		}
+		if g.Count <= 0 {
+			fail("count must be positive")
+		}
+		if !knownFormation(g.Formation) {
+			fail("unknown formation %q", g.Formation)
+		}
+		if g.Spacing < 0 {
+			fail("spacing must not be negative")
+		}
+		if g.MinSpeed <= 0 || g.MaxSpeed < g.MinSpeed {
+			fail("speeds must be positive with minSpeed <= maxSpeed")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func knownEnemy(name string) bool {
+	_, ok := EnemyTypes[name]
+	return name == "" || ok
+}
+
+func knownFormation(f Formation) bool {
+	for _, known := range formations {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+// offsets returns the position of each of the count members of the
+// formation relative to its leader. Members trail above the leader so the
+// formation flies down into the screen.
+func (f Formation) offsets(count int, spacing float64) [][2]float64 {
+	offsets := make([][2]float64, count)
+	for i := range offsets {
+		switch f {
+		case FormationLine:
+			offsets[i] = [2]float64{(float64(i) - float64(count-1)/2) * spacing, 0}
+		case FormationColumn:
+			offsets[i] = [2]float64{0, -float64(i) * spacing}
+		case FormationV:
+			// Alternate between the left and right wing
+			rank := float64((i + 1) / 2)
+			side := 1.0
+			if i%2 == 1 {
+				side = -1
+			}
+			offsets[i] = [2]float64{side * rank * spacing, -rank * spacing}
+		case FormationCircle:
+			radius := math.Max(spacing, spacing*float64(count)/(2*math.Pi))
+			angle := 2 * math.Pi * float64(i) / float64(count)
+			offsets[i] = [2]float64{radius * math.Sin(angle), -radius + radius*math.Cos(angle)}
+		}
+	}
+	return offsets
+}
+
+// Spawner decides which enemies enter the world. Update is called once per
+// tick.
+type Spawner interface {
+	Update(w *World)
+}
+
+// spawnGroup brings in all enemies of g at once.
+func (w *World) spawnGroup(g Group) {
+	x := w.rng.Float64() * ScreenWidth
+	if g.X != nil {
+		x = *g.X
+	}
+	// Share one speed so the formation keeps its shape
+	speed := g.MinSpeed + w.rng.Float64()*(g.MaxSpeed-g.MinSpeed)
+	typ := g.Enemy
+	if typ == "" {
+		typ = DefaultEnemy
+	}
+	for _, off := range g.Formation.offsets(g.Count, g.Spacing) {
+		w.spawnEnemy(newEnemy(typ, x+off[0]-entitySize/2, g.Y+off[1], speed))
+	}
+}
+
+func seconds(s float64) int {
+	return int(math.Round(s * TicksPerSecond))
+}
+
+// WaveScript plays a list of waves one after another, starting over after
+// the last one.
+type WaveScript struct {
+	waves []Wave
+	wave  int
+	tick  int
+}
+
+func NewWaveScript(waves []Wave) *WaveScript {
+	return &WaveScript{waves: waves}
+}
+
+func (s *WaveScript) Update(w *World) {
+	if len(s.waves) == 0 {
+		return
+	}
+	wave := s.waves[s.wave]
+	for _, g := range wave.Groups {
+		if seconds(g.Delay) == s.tick {
+			w.spawnGroup(g)
+		}
+	}
+	s.tick++
+	if s.tick >= seconds(wave.Duration) {
+		s.wave = (s.wave + 1) % len(s.waves)
+		s.tick = 0
+	}
+}
+
+// Escalation generates an endless stream of groups for Competition mode.
+// Every escalationStep the groups get larger, faster and more frequent, and
+// another enemy type from escalationEnemies joins the mix.
+type Escalation struct {
+	tick int
+	next int // Tick the next group spawns at
+}
+
+const escalationStep = 20 * TicksPerSecond
+
+var escalationEnemies = []string{"fighter", "zigzag", "drone", "diver", "turret", "bomber"}
+
+func NewEscalation() *Escalation {
+	return &Escalation{next: TicksPerSecond}
+}
+
+// Difficulty returns how many times the escalation has ramped up.
+func (e *Escalation) Difficulty() int {
+	return e.tick / escalationStep
+}
+
+func (e *Escalation) Update(w *World) {
+	e.tick++
+	if e.tick < e.next {
+		return
+	}
+	level := e.Difficulty()
+	maxExtra := level
+	if maxExtra > 6 {
+		maxExtra = 6
+	}
+	unlocked := level + 1
+	if unlocked > len(escalationEnemies) {
+		unlocked = len(escalationEnemies)
+	}
+	g := Group{
+		Enemy:     escalationEnemies[w.rng.Intn(unlocked)],
+		Count:     1 + w.rng.Intn(maxExtra+1),
+		Formation: formations[w.rng.Intn(len(formations))],
+		Spacing:   40,
+		MinSpeed:  math.Min(3+0.25*float64(level), 6),
+	}
+	g.MaxSpeed = g.MinSpeed + 1
+	// Keep the whole formation on screen
+	half := g.Spacing * float64(g.Count) / 2
+	x := half + w.rng.Float64()*math.Max(ScreenWidth-2*half, 0)
+	g.X = &x
+	w.spawnGroup(g)
+
+	interval := math.Max(1.5, 3.5-0.25*float64(level))
+	e.next = e.tick + seconds(interval)
+}
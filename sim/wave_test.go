@@ -0,0 +1,73 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWaveValidate(t *testing.T) {
+	group := func(delay float64) Group {
+		return Group{Delay: delay, Count: 1, Formation: FormationLine, MinSpeed: 1, MaxSpeed: 1}
+	}
+	tests := []struct {
+		name string
+		wave Wave
+		want string // Part of the error, empty if valid
+	}{
+		{"valid", Wave{Duration: 2, Groups: []Group{group(0), group(1.99)}}, ""},
+		{"no duration", Wave{Duration: 0}, "duration"},
+		{"duration under a tick", Wave{Duration: 0.005}, "duration"},
+		{"negative delay", Wave{Duration: 2, Groups: []Group{group(-1)}}, "delay"},
+		{"delay after the wave", Wave{Duration: 2, Groups: []Group{group(3)}}, "delay"},
+		{"delay rounding to the end", Wave{Duration: 2, Groups: []Group{group(1.995)}}, "delay"},
+		{"unknown enemy", Wave{Duration: 2, Groups: []Group{{Enemy: "ufo", Count: 1, Formation: FormationLine, MinSpeed: 1, MaxSpeed: 1}}}, "unknown enemy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.wave.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("got %v, want no error", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("got %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaveScriptSpawnsEveryGroup(t *testing.T) {
+	group := func(delay float64, count int) Group {
+		return Group{Delay: delay, Count: count, Formation: FormationLine, Spacing: 40, MinSpeed: 1, MaxSpeed: 1}
+	}
+	waves := []Wave{
+		{Duration: 1, Groups: []Group{group(0, 1), group(0.991, 2)}},
+		{Duration: 0.5, Groups: []Group{group(0.25, 3)}},
+	}
+	for i, wave := range waves {
+		if err := wave.Validate(); err != nil {
+			t.Fatalf("wave %d: %v", i+1, err)
+		}
+	}
+
+	w := newTestWorld()
+	w.Spawner = NewWaveScript(waves)
+	spawned := func() int {
+		n := 0
+		for _, e := range w.Enemies.Items {
+			if e.Active {
+				n++
+			}
+		}
+		return n
+	}
+	// One round through both waves
+	run(w, Input{}, seconds(1)+seconds(0.5))
+	if got := spawned(); got != 6 {
+		t.Errorf("spawned %d enemies in the first round, want 6", got)
+	}
+	// The script starts over after the last wave
+	run(w, Input{}, 1)
+	if got := spawned(); got != 7 {
+		t.Errorf("spawned %d enemies after starting over, want 7", got)
+	}
+}
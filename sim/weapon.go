@@ -0,0 +1,224 @@
+package sim
+
+import "math"
+
+// WeaponKind names a weapon the player can carry.
+type WeaponKind string
+
+const (
+	WeaponNone   WeaponKind = ""
+	WeaponCannon WeaponKind = "cannon" // Straight shots, more barrels with every level
+	WeaponSpread WeaponKind = "spread" // A fan of shots
+	WeaponLaser  WeaponKind = "laser"  // Fast bolts piercing through enemies
+	WeaponCharge WeaponKind = "charge" // Hold fire to charge a heavy shot
+	WeaponHoming WeaponKind = "homing" // Missiles seeking the nearest enemy
+	WeaponRear   WeaponKind = "rear"   // Guns covering the back
+)
+
+var (
+	PrimaryWeapons   = []WeaponKind{WeaponCannon, WeaponSpread, WeaponLaser, WeaponCharge}
+	SecondaryWeapons = []WeaponKind{WeaponNone, WeaponHoming, WeaponRear}
+)
+
+// Loadout is the pair of weapons the player flies with.
+type Loadout struct {
+	Primary   WeaponKind
+	Secondary WeaponKind
+}
+
+var DefaultLoadout = Loadout{Primary: WeaponCannon}
+
+const (
+	MaxWeaponLevel = 3
+
+	// maxCharge is the number of ticks a charge shot takes to fully charge,
+	// minCharge the number needed for any charge to count.
+	maxCharge = 60
+	minCharge = 10
+
+	// homingTurn is how far a missile turns per tick, in radians.
+	homingTurn = 0.08
+)
+
+// updateWeapons fires the player's weapons. Weapons fire on their own while
+// auto-fire is on and while the fire button is held otherwise; the charge
+// shot charges while the button is held and fires on release.
+func (w *World) updateWeapons(in Input) {
+	p := &w.Player
+	if p.PrimaryCooldown > 0 {
+		p.PrimaryCooldown--
+	}
+	if p.SecondaryCooldown > 0 {
+		p.SecondaryCooldown--
+	}
+	firing := p.AutoFire || in.Fire
+
+	if p.Loadout.Primary == WeaponCharge && (in.Fire || p.Charge > 0) {
+		if in.Fire {
+			p.Charge = int(math.Min(float64(p.Charge+1), maxCharge))
+		} else {
+			if p.Charge >= minCharge {
+				w.fireCharged(p.Charge)
+				p.PrimaryCooldown = w.cooldown(20)
+				w.emit(EventPlayerFire, p.X, p.Y)
+			}
+			p.Charge = 0
+		}
+	} else if firing && p.PrimaryCooldown == 0 {
+		p.PrimaryCooldown = w.cooldown(w.firePrimary())
+		w.emit(EventPlayerFire, p.X, p.Y)
+	}
+
+	if firing && p.Loadout.Secondary != WeaponNone && p.SecondaryCooldown == 0 {
+		p.SecondaryCooldown = w.cooldown(w.fireSecondary())
+	}
+}
+
+// cooldown shortens a weapon cooldown while rapid fire is active.
+func (w *World) cooldown(ticks int) int {
+	if t, ok := w.effect(PowerUpRapid); ok {
+		return int(math.Ceil(float64(ticks) * t.Value))
+	}
+	return ticks
+}
+
+// shoot adds a player bullet leaving the nose of the aircraft at the given
+// horizontal offset, flying at angle (radians, -π/2 is straight up).
+func (w *World) shoot(kind WeaponKind, offsetX, angle, speed float64, damage int) *PlayerBullet {
+	playerCenterX := w.Player.X + 17 - 2 // 17 is half of the player image width (34/2) and 2 is half of the bullet image width (4/2).
+	i := w.PlayerBullets.Add(PlayerBullet{
+		Kind:   kind,
+		X:      playerCenterX + offsetX,
+		Y:      w.Player.Y,
+		SpeedX: math.Cos(angle) * speed,
+		SpeedY: math.Sin(angle) * speed,
+		Damage: damage,
+		Active: true,
+	})
+	return &w.PlayerBullets.Items[i]
+}
+
+// firePrimary fires the primary weapon and returns its cooldown in ticks.
+func (w *World) firePrimary() int {
+	level := w.Player.WeaponLevel
+	up := -math.Pi / 2
+	switch w.Player.Loadout.Primary {
+	case WeaponSpread:
+		const step = math.Pi / 18
+		n := 2*level + 1
+		for i := 0; i < n; i++ {
+			w.shoot(WeaponSpread, 0, up+(float64(i)-float64(n-1)/2)*step, 5, 1)
+		}
+		return 24
+	case WeaponLaser:
+		w.shoot(WeaponLaser, 0, up, 12, 1).Pierce = true
+		return 7 - level
+	case WeaponCharge:
+		// Uncharged shots while auto-firing
+		w.shoot(WeaponCharge, 0, up, 5, 1)
+		return 30
+	default:
+		// One more barrel with every level
+		for i := 0; i < level; i++ {
+			w.shoot(WeaponCannon, (float64(i)-float64(level-1)/2)*8, up, 5, 1)
+		}
+		if level == MaxWeaponLevel {
+			return 15
+		}
+		return 20
+	}
+}
+
+// fireCharged releases a charge shot, dealing more damage the longer it was
+// charged. A full charge pierces through enemies.
+func (w *World) fireCharged(charge int) {
+	damage := 1 + w.Player.WeaponLevel*charge*2/maxCharge
+	w.shoot(WeaponCharge, 0, -math.Pi/2, 6, damage).Pierce = charge == maxCharge
+}
+
+// fireSecondary fires the secondary weapon and returns its cooldown in
+// ticks.
+func (w *World) fireSecondary() int {
+	level := w.Player.WeaponLevel
+	switch w.Player.Loadout.Secondary {
+	case WeaponHoming:
+		// A second missile from level 2 on
+		for i := 0; i < level && i < 2; i++ {
+			side := float64(2*i - 1)
+			w.shoot(WeaponHoming, side*12, -math.Pi/2+side*0.6, 4, 2).Homing = true
+		}
+		return 75 - 15*level
+	case WeaponRear:
+		down := math.Pi / 2
+		w.shoot(WeaponRear, 0, down, 5, 1)
+		if level >= 2 {
+			w.shoot(WeaponRear, 0, down-math.Pi/8, 5, 1)
+			w.shoot(WeaponRear, 0, down+math.Pi/8, 5, 1)
+		}
+		if level >= 3 {
+			w.shoot(WeaponRear, 0, 0, 5, 1)
+			w.shoot(WeaponRear, 0, math.Pi, 5, 1)
+		}
+		return 30
+	}
+	return 0
+}
+
+// steer turns a homing missile towards the nearest target.
+func (w *World) steer(b *PlayerBullet) {
+	tx, ty, ok := w.nearestTarget(b.X, b.Y)
+	if !ok {
+		return
+	}
+	speed := math.Hypot(b.SpeedX, b.SpeedY)
+	heading := math.Atan2(b.SpeedY, b.SpeedX)
+	diff := math.Remainder(math.Atan2(ty-b.Y, tx-b.X)-heading, 2*math.Pi)
+	heading += clamp(diff, -homingTurn, homingTurn)
+	b.SpeedX, b.SpeedY = math.Cos(heading)*speed, math.Sin(heading)*speed
+}
+
+// nearestTarget returns the center of the active enemy or boss closest to
+// (x, y).
+func (w *World) nearestTarget(x, y float64) (tx, ty float64, ok bool) {
+	best := math.Inf(1)
+	consider := func(cx, cy float64) {
+		if d := (cx-x)*(cx-x) + (cy-y)*(cy-y); d < best {
+			best, tx, ty, ok = d, cx, cy, true
+		}
+	}
+	for i := range w.Enemies.Items {
+		if e := &w.Enemies.Items[i]; e.Active && e.Y >= 0 {
+			consider(e.X+entitySize/2, e.Y+entitySize/2)
+		}
+	}
+	if w.BossActive {
+		consider(w.Boss.X+entitySize/2, w.Boss.Y+entitySize/2)
+	}
+	return tx, ty, ok
+}
+
+// Hitbox returns the hitbox of the bullet; charged shots grow with their
+// damage.
+func (b *PlayerBullet) Hitbox() Hitbox {
+	if b.Kind == WeaponCharge && b.Damage > 1 {
+		return Hitbox{Shape: ShapeCircle, X: 2, Y: 8, R: 4 + 2*float64(b.Damage)}
+	}
+	return Hitboxes.PlayerBullet
+}
+
+// hit applies the bullet to the target identified by id (the ID of an
+// enemy, or -1 for the boss) and reports whether it connected. Piercing
+// bullets carry on but hit every target only once.
+func (b *PlayerBullet) hit(id int) bool {
+	if b.Pierce {
+		for _, hit := range b.Hits {
+			if hit == id {
+				return false
+			}
+		}
+		b.Hits = append(b.Hits, id)
+		return true
+	}
+	b.Active = false
+	return true
+}
@@ -0,0 +1,393 @@
+// Package sim contains the gameplay rules of Ghost of Kyiv. It has no
+// dependency on Ebiten so the whole game can be stepped headlessly, e.g. on
+// CI machines without a display.
+package sim
+
+import (
+	"math"
+	"math/rand"
+)
+
+const (
+	ScreenWidth  = 640
+	ScreenHeight = 480
+
+	// TicksPerSecond is the fixed rate the simulation advances at.
+	TicksPerSecond = 60
+
+	// entitySize is the size of the square every entity occupies.
+	entitySize = 32
+)
+
+// tickDuration is the length of one simulation tick in seconds.
+const tickDuration = 1.0 / TicksPerSecond
+
+// timestepDrift is how far the accumulated time may fall short of a tick
+// and still run it, which leaves the accumulator that far below zero.
+const timestepDrift = 1e-9
+
+// Input is the player input the simulation reacts to during a step.
+type Input struct {
+	Left, Right, Up, Down bool
+	MoveX, MoveY          float64 // Analog movement from -1 to 1
+	Fire                  bool
+	Bomb                  bool
+}
+
+// EventKind is what happened in an event.
+type EventKind int
+
+const (
+	EventEnemyHit EventKind = iota
+	EventBossHit
+	EventBossPhase
+	EventBossDefeated
+	EventBomb
+	EventPlayerHit
+	EventEnemyDestroyed
+	EventPlayerFire
+	EventBulletImpact // A player bullet hit an enemy or the boss
+)
+
+// Event reports something that happened during a step which the frontend
+// may want to react to (sounds, screens, effects, ...).
+type Event struct {
+	Kind EventKind
+	X, Y float64 // Center of the entity it happened to, or where a bullet hit
+}
+
+// FlashDuration is how many ticks enemies and the boss flash for when hit.
+const FlashDuration = 6
+
+// World holds the complete gameplay state.
+type World struct {
+	Player        Player
+	Enemies       Pool[Enemy]
+	PlayerBullets Pool[PlayerBullet]
+	EnemyBullets  Pool[EnemyBullet]
+	Boss          Boss
+	BossActive    bool
+	PowerUps      Pool[PowerUp]
+	Score         int // Score of the current level
+	RunScore      int // Score of the levels of the run before the current one
+	Lives         int
+	Frame         int // Keep track of frames for shooting timer
+	BgOffsetY     float64
+	Spawner       Spawner
+	Effects       map[PowerUpKind]int // Ticks left of every active power-up effect
+
+	// Invulnerability is how many seconds the player can't be hit for after
+	// respawning.
+	Invulnerability float64
+
+	src            *Source
+	rng            *rand.Rand // Draws from src
+	powerUpCounter int
+	enemyIDs       int // Last ID given to an enemy
+	slowClock      float64
+	bombHeld       bool // Whether the bomb button was down the tick before
+	accumulator    float64
+	events         []Event
+	enemyGrid      *grid // Broad phase for player bullets against enemies
+}
+
+// NewWorld creates a world drawing all randomness from src.
+func NewWorld(src *Source) *World {
+	w := &World{src: src, rng: rand.New(src), enemyGrid: newGrid(ScreenWidth, ScreenHeight)}
+	w.Player.Speed = 4
+	w.Player.Loadout = DefaultLoadout
+	w.Player.AutoFire = true
+	w.Invulnerability = DefaultInvulnerability
+	w.Reset()
+	return w
+}
+
+// Reseed restarts the random source from seed.
+func (w *World) Reseed(seed int64) {
+	w.rng.Seed(seed)
+}
+
+// Reset puts the world back into the state of a fresh game.
+func (w *World) Reset() {
+	w.Player.X = ScreenWidth / 2
+	w.Player.Y = spawnY
+	w.Player.WeaponLevel = 1
+	w.Player.Charge = 0
+	w.Player.PrimaryCooldown = 0
+	w.Player.SecondaryCooldown = 0
+	w.Player.Bombs = BombStock
+	w.Player.setState(PlayerAlive, 0)
+	w.bombHeld = false
+	w.Enemies.Reset()
+	w.PlayerBullets.Reset()
+	w.EnemyBullets.Reset()
+	w.Lives = 3
+	w.Score = 0
+	w.RunScore = 0
+	w.BgOffsetY = 0
+	w.Spawner = NewEscalation()
+	w.PowerUps.Reset()
+	w.powerUpCounter = 0
+	w.enemyIDs = 0
+	w.Effects = map[PowerUpKind]int{}
+	w.slowClock = 0
+	w.Frame = 0
+	w.accumulator = 0
+}
+
+// ResetLevel clears the entities and score of the current level, adding the
+// score to that of the run, and starts playing its waves.
+func (w *World) ResetLevel(waves []Wave) {
+	w.Enemies.Reset()
+	w.PlayerBullets.Reset()
+	w.EnemyBullets.Reset()
+	w.Boss = Boss{}
+	w.BossActive = false
+	w.RunScore += w.Score
+	w.Score = 0
+	w.Spawner = NewWaveScript(waves)
+}
+
+// TotalScore returns the score of the whole run, across levels.
+func (w *World) TotalScore() int {
+	return w.RunScore + w.Score
+}
+
+// ClearBullets removes every bullet in flight.
+func (w *World) ClearBullets() {
+	w.PlayerBullets.Reset()
+	w.EnemyBullets.Reset()
+}
+
+// GameOver reports whether the player has run out of lives and the last
+// aircraft has finished exploding.
+func (w *World) GameOver() bool {
+	return w.Lives <= 0 && (w.Player.State != PlayerExploding || w.Player.StateTicks == 0)
+}
+
+// Step advances the world by dt seconds using a fixed timestep and returns
+// the events that happened meanwhile. The returned slice is only valid until
+// the next call.
+func (w *World) Step(in Input, dt float64) []Event {
+	w.events = w.events[:0]
+	w.accumulator += dt
+	// Allow for floating point drift so a dt of exactly one tick always
+	// advances the world by one tick.
+	for w.accumulator >= tickDuration-timestepDrift {
+		w.accumulator -= tickDuration
+		w.tick(in)
+	}
+	return w.events
+}
+
+func (w *World) emit(kind EventKind, x, y float64) {
+	w.events = append(w.events, Event{Kind: kind, X: x + entitySize/2, Y: y + entitySize/2})
+}
+
+// impact reports that b hit something, at the tip of the bullet.
+func (w *World) impact(b *PlayerBullet) {
+	x0, y0, x1, _ := place(b.Hitbox(), b.X, b.Y).bounds()
+	w.events = append(w.events, Event{Kind: EventBulletImpact, X: (x0 + x1) / 2, Y: y0})
+}
+
+func (w *World) tick(in Input) {
+	w.sweep()
+
+	w.updatePlayer()
+	if w.Player.Controllable() {
+		// Player controls
+		if in.Left {
+			w.Player.X -= w.Player.Speed
+		}
+		if in.Right {
+			w.Player.X += w.Player.Speed
+		}
+		if in.Up {
+			w.Player.Y -= w.Player.Speed
+		}
+		if in.Down {
+			w.Player.Y += w.Player.Speed
+		}
+		// The analog stick moves the player up to full speed along the axes
+		// no direction button is held for
+		if !in.Left && !in.Right {
+			w.Player.X += clamp(in.MoveX, -1, 1) * w.Player.Speed
+		}
+		if !in.Up && !in.Down {
+			w.Player.Y += clamp(in.MoveY, -1, 1) * w.Player.Speed
+		}
+
+		// Ensure player stays within screen bounds
+		w.Player.X = clamp(w.Player.X, 0, ScreenWidth-entitySize)
+		w.Player.Y = clamp(w.Player.Y, 0, ScreenHeight-entitySize)
+		// Shooting behavior
+		w.updateWeapons(in)
+	}
+	w.updateBomb(in)
+
+	// Update player bullets and drop those that left the screen
+	for i := range w.PlayerBullets.Items {
+		b := &w.PlayerBullets.Items[i]
+		if b.Active {
+			if b.Homing {
+				w.steer(b)
+			}
+			b.X += b.SpeedX
+			b.Y += b.SpeedY
+			if b.X < -entitySize || b.X > ScreenWidth || b.Y < -entitySize || b.Y > ScreenHeight {
+				b.Active = false
+			}
+		}
+	}
+
+	// Increment the frame count
+	w.Frame++
+
+	// Enemies sit out some ticks while time is slowed down
+	frozen := w.updateSlowTime()
+
+	// Update enemies and ensure they move on the y-axis from top to bottom
+	for i := range w.Enemies.Items {
+		e := &w.Enemies.Items[i]
+		if !e.Active {
+			continue
+		}
+		t := EnemyTypes[e.Type]
+		if e.Flash > 0 {
+			e.Flash--
+		}
+		if !frozen {
+			w.moveEnemy(e, t)
+		}
+		if e.Y > (ScreenHeight - entitySize) {
+			e.Active = false
+		}
+		// Check for collision with player
+		if e.Active && overlaps(place(t.Hitbox, e.X, e.Y), w.playerBody()) {
+			w.hitPlayer()
+		}
+	}
+
+	// Check for collisions between enemies and player bullets
+	w.collideEnemies()
+
+	// Spawn enemies
+	if w.Spawner != nil && !frozen {
+		w.Spawner.Update(w)
+	}
+
+	// Update enemies and ensure they stay within screen bounds. Formations
+	// may start above the screen and fly in from there.
+	for i := range w.Enemies.Items {
+		if e := &w.Enemies.Items[i]; e.Active {
+			e.X = clamp(e.X, 0, ScreenWidth-entitySize)
+			e.Y = math.Min(e.Y, ScreenHeight-entitySize)
+		}
+	}
+
+	// Update enemy bullets and drop those that left the screen
+	for i := range w.EnemyBullets.Items {
+		b := &w.EnemyBullets.Items[i]
+		if b.Active {
+			if !frozen {
+				b.X += b.SpeedX
+				b.Y += b.SpeedY
+			}
+			if b.X < -entitySize || b.X > ScreenWidth || b.Y < -entitySize || b.Y > ScreenHeight {
+				b.Active = false
+				continue
+			}
+
+			// Check for collision with player
+			if w.Player.State == PlayerAlive && overlaps(w.playerBody(), place(Hitboxes.EnemyBullet, b.X, b.Y)) {
+				w.hitPlayer()
+				b.Active = false
+			}
+		}
+	}
+
+	// Enemy shooting logic
+	for i := 0; i < len(w.Enemies.Items) && !frozen; i++ {
+		if e := &w.Enemies.Items[i]; e.Active {
+			w.enemyFire(e, EnemyTypes[e.Type])
+		}
+	}
+
+	// Handle boss logic (only if the boss is active)
+	if w.BossActive && w.updateBoss(frozen) {
+		return
+	}
+
+	w.updatePowerUps()
+
+	// Update the background scrolling
+	w.BgOffsetY += 2
+}
+
+// sweep returns the slots of inactive entities to their pools.
+func (w *World) sweep() {
+	w.Enemies.sweep(func(e *Enemy) bool { return e.Active })
+	w.PlayerBullets.sweep(func(b *PlayerBullet) bool { return b.Active })
+	w.EnemyBullets.sweep(func(b *EnemyBullet) bool { return b.Active })
+	w.PowerUps.sweep(func(p *PowerUp) bool { return p.Active })
+}
+
+// collideEnemies resolves hits of player bullets on enemies, using a grid of
+// the enemies as broad phase.
+func (w *World) collideEnemies() {
+	w.enemyGrid.clear()
+	for i := range w.Enemies.Items {
+		if e := &w.Enemies.Items[i]; e.Active {
+			x0, y0, x1, y1 := place(EnemyTypes[e.Type].Hitbox, e.X, e.Y).bounds()
+			w.enemyGrid.insert(i, x0, y0, x1, y1)
+		}
+	}
+
+	for j := range w.PlayerBullets.Items {
+		b := &w.PlayerBullets.Items[j]
+		if !b.Active {
+			continue
+		}
+		bullet := place(b.Hitbox(), b.X, b.Y)
+		x0, y0, x1, y1 := bullet.bounds()
+		w.enemyGrid.each(x0, y0, x1, y1, func(i int) bool {
+			e := &w.Enemies.Items[i]
+			if !e.Active || !overlaps(place(EnemyTypes[e.Type].Hitbox, e.X, e.Y), bullet) || !b.hit(e.ID) {
+				return true
+			}
+			w.impact(b)
+			w.damageEnemy(e, b.Damage)
+			// A bullet hits one enemy per tick
+			return false
+		})
+	}
+}
+
+// damageEnemy takes damage off the health of e, destroying it once the
+// health runs out.
+func (w *World) damageEnemy(e *Enemy, damage int) {
+	e.Health -= damage
+	e.Flash = FlashDuration
+	w.emit(EventEnemyHit, e.X, e.Y)
+	if e.Health <= 0 {
+		e.Active = false
+		w.addScore(EnemyTypes[e.Type].Score)
+		w.dropFrom(e)
+		w.emit(EventEnemyDestroyed, e.X, e.Y)
+	}
+}
+
+func clamp(value, min, max float64) float64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// playerBody returns the core hitbox of the player in the world.
+func (w *World) playerBody() body {
+	return place(Hitboxes.Player, w.Player.X, w.Player.Y)
+}
@@ -0,0 +1,314 @@
+package sim
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// newTestWorld returns a world that spawns nothing and only fires when told
+// to, so tests place every entity themselves.
+func newTestWorld() *World {
+	w := NewWorld(NewSource(1))
+	w.Spawner = nil
+	w.Player.AutoFire = false
+	return w
+}
+
+// run steps w by the given number of ticks and returns the events.
+func run(w *World, in Input, ticks int) []Event {
+	var events []Event
+	for i := 0; i < ticks; i++ {
+		events = append(events, w.Step(in, tickDuration)...)
+	}
+	return events
+}
+
+func count(events []Event, kind EventKind) int {
+	n := 0
+	for _, e := range events {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// bulletOnPlayer adds a still enemy bullet over the core of the player.
+func bulletOnPlayer(w *World) {
+	h := Hitboxes.Player
+	w.EnemyBullets.Add(EnemyBullet{X: w.Player.X + h.X - 6, Y: w.Player.Y + h.Y - 6, Active: true})
+}
+
+func TestEnemyBulletCostsLife(t *testing.T) {
+	w := newTestWorld()
+	bulletOnPlayer(w)
+	events := run(w, Input{}, 1)
+
+	if w.Lives != 2 {
+		t.Errorf("lives = %d, want 2", w.Lives)
+	}
+	if w.Player.State != PlayerExploding {
+		t.Errorf("state = %v, want exploding", w.Player.State)
+	}
+	if count(events, EventPlayerHit) != 1 {
+		t.Errorf("got %d player hit events, want 1", count(events, EventPlayerHit))
+	}
+	if w.EnemyBullets.Items[0].Active {
+		t.Errorf("the bullet that hit is still active")
+	}
+}
+
+func TestEnemyRammingCostsLife(t *testing.T) {
+	w := newTestWorld()
+	w.Enemies.Add(newEnemy("diver", w.Player.X, w.Player.Y, 0))
+	run(w, Input{}, 1)
+	if w.Lives != 2 {
+		t.Errorf("lives = %d, want 2", w.Lives)
+	}
+}
+
+func TestMissDoesNotCostLife(t *testing.T) {
+	w := newTestWorld()
+	w.EnemyBullets.Add(EnemyBullet{X: 10, Y: 10, Active: true})
+	run(w, Input{}, 1)
+	if w.Lives != 3 || w.Player.State != PlayerAlive {
+		t.Errorf("lives = %d, state = %v after a miss", w.Lives, w.Player.State)
+	}
+}
+
+func TestRespawnAndInvulnerability(t *testing.T) {
+	w := newTestWorld()
+	w.Invulnerability = 1
+	bulletOnPlayer(w)
+	run(w, Input{}, 1)
+
+	run(w, Input{}, explosionDuration)
+	if w.Player.State != PlayerRespawning {
+		t.Fatalf("state = %v after the explosion, want respawning", w.Player.State)
+	}
+	run(w, Input{}, respawnDuration)
+	if w.Player.State != PlayerInvulnerable {
+		t.Fatalf("state = %v after respawning, want invulnerable", w.Player.State)
+	}
+	if w.Player.Y != spawnY {
+		t.Errorf("respawned at y = %v, want %v", w.Player.Y, spawnY)
+	}
+
+	// Hits don't count while invulnerable
+	bulletOnPlayer(w)
+	run(w, Input{}, 1)
+	if w.Lives != 2 {
+		t.Errorf("lives = %d after a hit while invulnerable, want 2", w.Lives)
+	}
+	w.ClearBullets()
+
+	run(w, Input{}, TicksPerSecond)
+	if w.Player.State != PlayerAlive {
+		t.Fatalf("state = %v after the invulnerability ran out, want alive", w.Player.State)
+	}
+	bulletOnPlayer(w)
+	run(w, Input{}, 1)
+	if w.Lives != 1 {
+		t.Errorf("lives = %d after a hit once vulnerable, want 1", w.Lives)
+	}
+}
+
+func TestShieldAbsorbsHit(t *testing.T) {
+	w := newTestWorld()
+	w.Effects[PowerUpShield] = TicksPerSecond
+	bulletOnPlayer(w)
+	run(w, Input{}, 1)
+	if w.Lives != 3 || w.Player.State != PlayerAlive {
+		t.Errorf("lives = %d, state = %v with a shield", w.Lives, w.Player.State)
+	}
+}
+
+func TestGameOver(t *testing.T) {
+	w := newTestWorld()
+	w.Lives = 1
+	bulletOnPlayer(w)
+	run(w, Input{}, 1)
+	if w.GameOver() {
+		t.Errorf("game over while the last aircraft is still exploding")
+	}
+	run(w, Input{}, explosionDuration)
+	if !w.GameOver() {
+		t.Errorf("no game over after losing the last life")
+	}
+	if w.Player.State == PlayerRespawning {
+		t.Errorf("respawned without lives")
+	}
+}
+
+// shotAt adds a still player bullet over the enemy at (x, y).
+func shotAt(w *World, x, y float64, damage int) {
+	w.PlayerBullets.Add(PlayerBullet{Kind: WeaponCannon, X: x + 10, Y: y + 10, Damage: damage, Active: true})
+}
+
+func TestBulletDestroysEnemy(t *testing.T) {
+	w := newTestWorld()
+	w.Enemies.Add(newEnemy("fighter", 100, 100, 0))
+	shotAt(w, 100, 100, 1)
+	events := run(w, Input{}, 1)
+
+	if w.Enemies.Items[0].Active {
+		t.Errorf("enemy survived a hit")
+	}
+	if w.PlayerBullets.Items[0].Active {
+		t.Errorf("bullet carried on after hitting")
+	}
+	if w.Score != EnemyTypes["fighter"].Score {
+		t.Errorf("score = %d, want %d", w.Score, EnemyTypes["fighter"].Score)
+	}
+	for _, kind := range []EventKind{EventEnemyHit, EventEnemyDestroyed, EventBulletImpact} {
+		if count(events, kind) != 1 {
+			t.Errorf("got %d events of kind %d, want 1", count(events, kind), kind)
+		}
+	}
+}
+
+func TestEnemyHealth(t *testing.T) {
+	w := newTestWorld()
+	w.Enemies.Add(newEnemy("turret", 100, 100, 0))
+	health := EnemyTypes["turret"].Health
+	for i := 1; i < health; i++ {
+		shotAt(w, 100, 100, 1)
+		run(w, Input{}, 1)
+		if !w.Enemies.Items[0].Active {
+			t.Fatalf("destroyed after %d of %d hits", i, health)
+		}
+	}
+	if w.Score != 0 {
+		t.Errorf("score = %d before the enemy is destroyed", w.Score)
+	}
+	shotAt(w, 100, 100, 1)
+	run(w, Input{}, 1)
+	if w.Enemies.Items[0].Active {
+		t.Errorf("survived %d hits", health)
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		enemy      string
+		multiplier bool
+		want       int
+	}{
+		{"fighter", "fighter", false, EnemyTypes["fighter"].Score},
+		{"bomber", "bomber", false, EnemyTypes["bomber"].Score},
+		{"multiplied", "bomber", true, int(float64(EnemyTypes["bomber"].Score) * PowerUpTypes[PowerUpScore].Value)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWorld()
+			if tt.multiplier {
+				w.Effects[PowerUpScore] = TicksPerSecond
+			}
+			w.Enemies.Add(newEnemy(tt.enemy, 100, 100, 0))
+			shotAt(w, 100, 100, EnemyTypes[tt.enemy].Health)
+			run(w, Input{}, 1)
+			if w.Score != tt.want {
+				t.Errorf("score = %d, want %d", w.Score, tt.want)
+			}
+		})
+	}
+}
+
+func TestBombBonus(t *testing.T) {
+	w := newTestWorld()
+	w.Score = 10
+	w.AwardBombBonus()
+	if want := 10 + BombStock*BombBonus; w.Score != want {
+		t.Errorf("score = %d, want %d", w.Score, want)
+	}
+	if w.Player.Bombs != 0 {
+		t.Errorf("%d bombs left after the bonus", w.Player.Bombs)
+	}
+}
+
+// BenchmarkWorldStep steps a crowded world, keeping thousands of bullets of
+// both sides and hundreds of enemies on screen.
+func BenchmarkWorldStep(b *testing.B) {
+	w := newTestWorld()
+	w.Invulnerability = 1 << 30
+	r := rand.New(rand.NewSource(2))
+	fill := func() {
+		for w.PlayerBullets.Len() < 2000 {
+			w.PlayerBullets.Add(PlayerBullet{Kind: WeaponCannon, X: r.Float64() * ScreenWidth, Y: ScreenHeight, SpeedY: -1 - r.Float64()*4, Damage: 1, Active: true})
+		}
+		for w.Enemies.Len() < 300 {
+			e := newEnemy("fighter", r.Float64()*(ScreenWidth-entitySize), r.Float64()*200, 0.5)
+			e.Health = 1 << 30
+			w.Enemies.Add(e)
+		}
+		for w.EnemyBullets.Len() < 2000 {
+			w.EnemyBullets.Add(EnemyBullet{X: r.Float64() * ScreenWidth, SpeedY: 2, Active: true})
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		fill()
+		b.StartTimer()
+		w.Step(Input{}, tickDuration)
+	}
+}
+
+func TestPiercingBulletHitsEachEnemyOnce(t *testing.T) {
+	w := newTestWorld()
+	for i := 0; i < 2; i++ {
+		e := newEnemy("fighter", 100, 100, 0)
+		e.Health = 10
+		w.spawnEnemy(e)
+	}
+	w.PlayerBullets.Add(PlayerBullet{Kind: WeaponLaser, X: 110, Y: 110, Damage: 1, Pierce: true, Active: true})
+	events := run(w, Input{}, 5)
+
+	if n := count(events, EventEnemyHit); n != 2 {
+		t.Errorf("got %d hits on two enemies, want 2", n)
+	}
+	for i, e := range w.Enemies.Items {
+		if e.Health != 9 {
+			t.Errorf("enemy %d health = %d, want 9", i, e.Health)
+		}
+	}
+	if !w.PlayerBullets.Items[0].Active {
+		t.Errorf("piercing bullet stopped")
+	}
+}
+
+func TestRunScore(t *testing.T) {
+	w := newTestWorld()
+	w.Score = 10
+	w.ResetLevel(nil)
+	w.Score = 5
+	if w.Score != 5 || w.TotalScore() != 15 {
+		t.Errorf("score = %d, total = %d, want 5 and 15", w.Score, w.TotalScore())
+	}
+	w.Reset()
+	if w.TotalScore() != 0 {
+		t.Errorf("total = %d after a reset", w.TotalScore())
+	}
+}
+
+func TestPiercingBulletHitsEnemyInReusedSlot(t *testing.T) {
+	w := newTestWorld()
+	w.spawnEnemy(newEnemy("fighter", 100, 100, 0))
+	w.PlayerBullets.Add(PlayerBullet{Kind: WeaponLaser, X: 110, Y: 110, Damage: 1, Pierce: true, Active: true})
+	run(w, Input{}, 1)
+	if w.Enemies.Items[0].Active {
+		t.Fatalf("enemy survived the hit")
+	}
+
+	// A new enemy takes the freed slot while the bullet is still there
+	run(w, Input{}, 1)
+	w.spawnEnemy(newEnemy("fighter", 100, 100, 0))
+	if w.Enemies.Len() != 1 || !w.Enemies.Items[0].Active {
+		t.Fatalf("the new enemy didn't take the freed slot")
+	}
+	run(w, Input{}, 1)
+	if w.Enemies.Items[0].Active {
+		t.Errorf("the bullet passed through a new enemy in a slot it had hit")
+	}
+}
@@ -0,0 +1,118 @@
+// Package snapshot saves the complete state of a run, so it can be picked
+// up again exactly where it was left: after a quickload, after a crash, or
+// as the starting point of a test.
+//
+// A snapshot is JSON with a format version. Files written by older
+// versions are upgraded step by step by the migrations when they are read;
+// files of a newer version are refused.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"ghost/persist"
+	"ghost/sim"
+)
+
+// Version is the format version snapshots are written with.
+const Version = 1
+
+var ErrBadFormat = errors.New("snapshot: not a snapshot file")
+
+// Snapshot is a run frozen at the end of a frame.
+type Snapshot struct {
+	Version int    `json:"version"`
+	Mode    string `json:"mode"`    // "competition" or "story"
+	Chapter int    `json:"chapter"` // Story level being played
+	Level   int    `json:"level"`
+	Slot    int    `json:"slot"` // Save slot of a Story run, -1 if none
+	Seed    int64  `json:"seed"` // Seed the run was started with
+
+	World sim.State `json:"world"`
+}
+
+// migrations upgrade a decoded snapshot by one version each:
+// migrations[i] turns version i+1 into version i+2. A change to the format
+// bumps Version and appends the migration from the previous one.
+var migrations = []func(map[string]any) error{}
+
+// Encode writes s to w as the current version.
+func (s *Snapshot) Encode(w io.Writer) error {
+	s.Version = Version
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Decode reads a snapshot written by Encode, upgrading older versions.
+func Decode(r io.Reader) (*Snapshot, error) {
+	return decode(r, Version, migrations)
+}
+
+// decode reads a snapshot of version current or older, upgrading it with
+// migrations.
+func decode(r io.Reader, current int, migrations []func(map[string]any) error) (*Snapshot, error) {
+	// Numbers stay json.Number so seeds survive the round trip
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, ErrBadFormat
+	}
+	n, ok := doc["version"].(json.Number)
+	if !ok {
+		return nil, ErrBadFormat
+	}
+	v, err := n.Int64()
+	if err != nil {
+		return nil, ErrBadFormat
+	}
+	version := int(v)
+	if version < 1 || version > current {
+		return nil, fmt.Errorf("snapshot: unsupported version %d", version)
+	}
+	for ; version < current; version++ {
+		if err := migrations[version-1](doc); err != nil {
+			return nil, fmt.Errorf("snapshot: upgrading version %d: %w", version, err)
+		}
+		doc["version"] = version + 1
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// Save writes s to the file at path, creating its directory.
+func (s *Snapshot) Save(path string) error {
+	var buf bytes.Buffer
+	if err := s.Encode(&buf); err != nil {
+		return err
+	}
+	return persist.WriteFile(path, buf.Bytes())
+}
+
+// Load reads the snapshot file at path.
+func Load(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
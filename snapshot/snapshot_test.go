@@ -0,0 +1,107 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ghost/sim"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	w := sim.NewWorld(sim.NewSource(3))
+	for i := 0; i < 5*sim.TicksPerSecond; i++ {
+		w.Step(sim.Input{Fire: true}, 1.0/sim.TicksPerSecond)
+	}
+	state, err := w.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Above 2^53, where a float64 can no longer hold every integer
+	want := &Snapshot{Mode: "story", Chapter: 1, Level: 2, Slot: 0, Seed: 1<<53 + 1, World: state}
+
+	var buf bytes.Buffer
+	if err := want.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != Version {
+		t.Errorf("version %d, want %d", got.Version, Version)
+	}
+	if got.Seed != want.Seed {
+		t.Errorf("seed %d, want %d", got.Seed, want.Seed)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded a different snapshot")
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not JSON", "ghost"},
+		{"no version", `{"mode": "story"}`},
+		{"fractional version", `{"version": 1.5}`},
+		{"version 0", `{"version": 0}`},
+		{"newer version", fmt.Sprintf(`{"version": %d}`, Version+1)},
+	}
+	for _, tt := range tests {
+		if _, err := Decode(strings.NewReader(tt.data)); err == nil {
+			t.Errorf("%s: decoded %s", tt.name, tt.data)
+		}
+	}
+}
+
+func TestDecodeMigrates(t *testing.T) {
+	var ran []int
+	fake := []func(map[string]any) error{
+		func(doc map[string]any) error {
+			// Version 2 renamed "saveSlot" to "slot"
+			ran = append(ran, 1)
+			doc["slot"] = doc["saveSlot"]
+			delete(doc, "saveSlot")
+			return nil
+		},
+		func(doc map[string]any) error {
+			// Version 3 added the mode, and every older run was a story
+			ran = append(ran, 2)
+			doc["mode"] = "story"
+			return nil
+		},
+	}
+	data := `{"version": 1, "saveSlot": 2, "seed": 9007199254740993}`
+	s, err := decode(strings.NewReader(data), 3, fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ran, []int{1, 2}) {
+		t.Errorf("ran migrations %v, want [1 2]", ran)
+	}
+	if s.Version != 3 || s.Slot != 2 || s.Mode != "story" || s.Seed != 9007199254740993 {
+		t.Errorf("migrated to %+v", s)
+	}
+
+	// Version 2 files only need the last migration
+	ran = nil
+	if _, err := decode(strings.NewReader(`{"version": 2}`), 3, fake); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ran, []int{2}) {
+		t.Errorf("ran migrations %v, want [2]", ran)
+	}
+
+	failing := []func(map[string]any) error{
+		func(map[string]any) error { return errors.New("no slot") },
+	}
+	if _, err := decode(strings.NewReader(data), 2, failing); err == nil {
+		t.Errorf("decoded a file whose migration failed")
+	}
+}
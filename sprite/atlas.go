@@ -0,0 +1,40 @@
+package sprite
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+// Pack places rectangles of the given sizes in an atlas width pixels wide,
+// padding pixels apart so neighbours don't bleed into each other when
+// scaled. The rectangles go on shelves, tallest first. It returns where
+// every rectangle goes and the height of the atlas.
+func Pack(sizes []image.Point, width, padding int) ([]image.Rectangle, int, error) {
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return sizes[order[a]].Y > sizes[order[b]].Y
+	})
+
+	placed := make([]image.Rectangle, len(sizes))
+	x, y, shelf := padding, padding, 0
+	for _, i := range order {
+		size := sizes[i]
+		if size.X+2*padding > width {
+			return nil, 0, fmt.Errorf("sprite: a %dx%d sprite doesn't fit into an atlas %d pixels wide", size.X, size.Y, width)
+		}
+		if x+size.X+padding > width {
+			// Start a new shelf below the tallest sprite of this one
+			x, y, shelf = padding, y+shelf+padding, 0
+		}
+		placed[i] = image.Rectangle{Min: image.Pt(x, y), Max: image.Pt(x, y).Add(size)}
+		x += size.X + padding
+		if size.Y > shelf {
+			shelf = size.Y
+		}
+	}
+	return placed, y + shelf + padding, nil
+}
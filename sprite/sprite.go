@@ -0,0 +1,336 @@
+// Package sprite describes the sprites of the game and how they are
+// animated.
+//
+// A sheet names the sprites cut from the images, and the animations that
+// play them. A sprite is an image, or a region of one, and a strip of
+// frames of the same size side by side is cut into the sprites name/0,
+// name/1 and so on. An animation is a list of frames, each showing a
+// sprite for a duration, possibly scaled, tinted or flashing white, which
+// lets one image serve several animations:
+//
+//	{
+//	  "sprites": {
+//	    "player": {"image": "player.png"},
+//	    "explosion": {"image": "explosion.png", "frames": 8}
+//	  },
+//	  "animations": {
+//	    "player/left": {"frames": [{"sprite": "player", "scaleX": 0.8}]},
+//	    "explosion": {"loop": "once", "frames": [{"sprite": "explosion/0", "duration": 0.1}]}
+//	  }
+//	}
+package sprite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"sort"
+)
+
+// LoopMode is what an animation does after its last frame.
+type LoopMode string
+
+const (
+	Loop     LoopMode = "loop"     // Start over
+	Once     LoopMode = "once"     // Hold the last frame
+	PingPong LoopMode = "pingpong" // Play backwards, then forwards again
+)
+
+// DefaultDuration is how many seconds a frame lasts unless it says
+// otherwise.
+const DefaultDuration = 0.1
+
+// Sprite is where a sprite comes from.
+type Sprite struct {
+	Image string `json:"image"` // Path among the assets
+	// Region of the image, the whole image if W or H is 0
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+	// Frames cuts the region into this many sprites of equal width
+	Frames int `json:"frames"`
+}
+
+// Frame is a step of an animation.
+type Frame struct {
+	Sprite   string  `json:"sprite"`
+	Duration float64 `json:"duration"` // Seconds, DefaultDuration if 0
+	// ScaleX and ScaleY scale the sprite around its center, 1 if 0
+	ScaleX float64 `json:"scaleX"`
+	ScaleY float64 `json:"scaleY"`
+	// Tint multiplies the red, green and blue of the sprite, none if nil
+	Tint *[3]float64 `json:"tint"`
+	// Flash blends the sprite towards white, from 0 to 1
+	Flash float64 `json:"flash"`
+}
+
+// Scale returns how much the frame scales the sprite horizontally and
+// vertically.
+func (f Frame) Scale() (x, y float64) {
+	x, y = f.ScaleX, f.ScaleY
+	if x == 0 {
+		x = 1
+	}
+	if y == 0 {
+		y = 1
+	}
+	return x, y
+}
+
+// Animation is a named sequence of frames.
+type Animation struct {
+	Frames []Frame  `json:"frames"`
+	Loop   LoopMode `json:"loop"` // Loop if empty
+
+	order []int     // Indices into Frames of one run through the animation
+	ends  []float64 // Time every step of order ends at
+}
+
+// prepare works out the timing of the animation.
+func (a *Animation) prepare() {
+	a.order = a.order[:0]
+	for i := range a.Frames {
+		a.order = append(a.order, i)
+	}
+	if a.Loop == PingPong {
+		for i := len(a.Frames) - 2; i > 0; i-- {
+			a.order = append(a.order, i)
+		}
+	}
+	a.ends = a.ends[:0]
+	var t float64
+	for _, i := range a.order {
+		d := a.Frames[i].Duration
+		if d == 0 {
+			d = DefaultDuration
+		}
+		t += d
+		a.ends = append(a.ends, t)
+	}
+}
+
+// Duration returns the seconds one run through the animation takes.
+func (a *Animation) Duration() float64 {
+	return a.ends[len(a.ends)-1]
+}
+
+// FrameAt returns the frame shown t seconds after the animation started.
+func (a *Animation) FrameAt(t float64) Frame {
+	if a.Loop != Once {
+		t -= float64(int(t/a.Duration())) * a.Duration()
+	}
+	i := sort.SearchFloat64s(a.ends, t)
+	for i < len(a.ends) && a.ends[i] == t {
+		i++ // A frame ending at t is over
+	}
+	if i >= len(a.order) {
+		i = len(a.order) - 1
+	}
+	return a.Frames[a.order[i]]
+}
+
+// Sheet holds the sprites and animations.
+type Sheet struct {
+	Sprites    map[string]Sprite     `json:"sprites"`
+	Animations map[string]*Animation `json:"animations"`
+}
+
+// Load reads and validates the sheet at name in fsys.
+func Load(fsys fs.FS, name string) (*Sheet, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := &Sheet{}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(s); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, a := range s.Animations {
+		a.prepare()
+	}
+	return s, nil
+}
+
+// names returns the names of the sprites the sheet cuts from the images.
+func (s *Sheet) names() map[string]bool {
+	names := map[string]bool{}
+	for name, sp := range s.Sprites {
+		if sp.Frames > 1 {
+			for i := 0; i < sp.Frames; i++ {
+				names[fmt.Sprintf("%s/%d", name, i)] = true
+			}
+		} else {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// Validate reports every problem found in the sheet.
+func (s *Sheet) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	for name, sp := range s.Sprites {
+		if sp.Image == "" {
+			fail("sprite %q has no image", name)
+		}
+		if sp.X < 0 || sp.Y < 0 || sp.W < 0 || sp.H < 0 || sp.Frames < 0 {
+			fail("sprite %q: negative region or frames", name)
+		}
+		if sp.W > 0 && sp.Frames > sp.W {
+			fail("sprite %q: %d frames don't fit in %d pixels", name, sp.Frames, sp.W)
+		}
+	}
+	names := s.names()
+	for name, a := range s.Animations {
+		if a == nil {
+			fail("animation %q is null", name)
+			continue
+		}
+		if len(a.Frames) == 0 {
+			fail("animation %q has no frames", name)
+		}
+		switch a.Loop {
+		case "", Loop, Once, PingPong:
+		default:
+			fail("animation %q: unknown loop mode %q", name, a.Loop)
+		}
+		for i, f := range a.Frames {
+			if !names[f.Sprite] {
+				fail("animation %q: frame %d: unknown sprite %q", name, i+1, f.Sprite)
+			}
+			if f.Duration < 0 || f.Flash < 0 || f.Flash > 1 {
+				fail("animation %q: frame %d: duration must not be negative and flash must be from 0 to 1", name, i+1)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Require reports the animations of names the sheet lacks.
+func (s *Sheet) Require(names ...string) error {
+	var errs []error
+	for _, name := range names {
+		if _, ok := s.Animations[name]; !ok {
+			errs = append(errs, fmt.Errorf("no animation %q", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Images returns the paths of the images the sprites are cut from.
+func (s *Sheet) Images() []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, sp := range s.Sprites {
+		if !seen[sp.Image] {
+			seen[sp.Image] = true
+			paths = append(paths, sp.Image)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Region is the part of an image a sprite is cut from.
+type Region struct {
+	Image string // Path among the assets
+	Rect  image.Rectangle
+}
+
+// Regions returns where every sprite is cut from, given the bounds of the
+// images by path.
+func (s *Sheet) Regions(bounds map[string]image.Rectangle) (map[string]Region, error) {
+	regions := map[string]Region{}
+	for name, sp := range s.Sprites {
+		b, ok := bounds[sp.Image]
+		if !ok {
+			return nil, fmt.Errorf("sprite %q: no image %s", name, sp.Image)
+		}
+		r := b
+		if sp.W > 0 && sp.H > 0 {
+			r = image.Rect(sp.X, sp.Y, sp.X+sp.W, sp.Y+sp.H).Add(b.Min)
+		}
+		if !r.In(b) {
+			return nil, fmt.Errorf("sprite %q: region %v is outside of %s", name, r, sp.Image)
+		}
+		if sp.Frames <= 1 {
+			regions[name] = Region{Image: sp.Image, Rect: r}
+			continue
+		}
+		if sp.Frames > r.Dx() {
+			return nil, fmt.Errorf("sprite %q: %d frames don't fit in %d pixels", name, sp.Frames, r.Dx())
+		}
+		w := r.Dx() / sp.Frames
+		for i := 0; i < sp.Frames; i++ {
+			regions[fmt.Sprintf("%s/%d", name, i)] = Region{Image: sp.Image, Rect: image.Rect(r.Min.X+i*w, r.Min.Y, r.Min.X+(i+1)*w, r.Max.Y)}
+		}
+	}
+	return regions, nil
+}
+
+// Animator plays the animations of a sheet for one entity, switching
+// between them as the entity's state changes.
+type Animator struct {
+	sheet   *Sheet
+	name    string
+	anim    *Animation
+	elapsed float64
+}
+
+// NewAnimator returns an animator playing the named animation, which must
+// be one of the sheet.
+func NewAnimator(sheet *Sheet, name string) *Animator {
+	a := &Animator{sheet: sheet}
+	a.Restart(name)
+	return a
+}
+
+// Play switches to the named animation, unless it is already playing.
+func (a *Animator) Play(name string) {
+	if name != a.name {
+		a.Restart(name)
+	}
+}
+
+// Restart plays the named animation from the start. An unknown animation
+// keeps the current one.
+func (a *Animator) Restart(name string) {
+	anim, ok := a.sheet.Animations[name]
+	if !ok {
+		return
+	}
+	a.name, a.anim, a.elapsed = name, anim, 0
+}
+
+// Update advances the animation by dt seconds.
+func (a *Animator) Update(dt float64) {
+	a.elapsed += dt
+}
+
+// Playing returns the name of the animation playing.
+func (a *Animator) Playing() string {
+	return a.name
+}
+
+// Frame returns the frame to show now.
+func (a *Animator) Frame() Frame {
+	return a.anim.FrameAt(a.elapsed)
+}
+
+// Done reports whether an animation that plays once has ended.
+func (a *Animator) Done() bool {
+	return a.anim.Loop == Once && a.elapsed >= a.anim.Duration()
+}
@@ -0,0 +1,99 @@
+package sprite
+
+import (
+	"image"
+	"math"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		sheet string
+	}{
+		{"null animation", `{"sprites": {"a": {"image": "a.png"}}, "animations": {"idle": null}}`},
+		{"no frames", `{"sprites": {"a": {"image": "a.png"}}, "animations": {"idle": {"frames": []}}}`},
+		{"unknown sprite", `{"sprites": {"a": {"image": "a.png"}}, "animations": {"idle": {"frames": [{"sprite": "b"}]}}}`},
+		{"unknown loop", `{"sprites": {"a": {"image": "a.png"}}, "animations": {"idle": {"loop": "twice", "frames": [{"sprite": "a"}]}}}`},
+		{"no image", `{"sprites": {"a": {}}}`},
+		{"negative frames", `{"sprites": {"a": {"image": "a.png", "frames": -1}}}`},
+		{"frames wider than region", `{"sprites": {"a": {"image": "a.png", "w": 4, "h": 4, "frames": 5}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"sprites.json": {Data: []byte(tt.sheet)}}
+			if s, err := Load(fsys, "sprites.json"); err == nil {
+				t.Errorf("loaded %+v", s)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{"sprites.json": {Data: []byte(`{
+		"sprites": {"strip": {"image": "a.png", "frames": 2}},
+		"animations": {"idle": {"loop": "pingpong", "frames": [{"sprite": "strip/0"}, {"sprite": "strip/1", "duration": 0.2}]}}
+	}`)}}
+	s, err := Load(fsys, "sprites.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := s.Animations["idle"]
+	if d := a.Duration(); math.Abs(d-0.3) > 1e-9 {
+		t.Errorf("duration = %v, want 0.3", d)
+	}
+	if f := a.FrameAt(0.15); f.Sprite != "strip/1" {
+		t.Errorf("frame at 0.15s = %s, want strip/1", f.Sprite)
+	}
+}
+
+func TestRegions(t *testing.T) {
+	s := &Sheet{Sprites: map[string]Sprite{
+		"whole": {Image: "a.png"},
+		"part":  {Image: "a.png", X: 2, Y: 1, W: 4, H: 3},
+		"strip": {Image: "b.png", Frames: 3},
+	}}
+	bounds := map[string]image.Rectangle{"a.png": image.Rect(0, 0, 10, 10), "b.png": image.Rect(0, 0, 9, 2)}
+	regions, err := s.Regions(bounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]image.Rectangle{
+		"whole":   image.Rect(0, 0, 10, 10),
+		"part":    image.Rect(2, 1, 6, 4),
+		"strip/0": image.Rect(0, 0, 3, 2),
+		"strip/1": image.Rect(3, 0, 6, 2),
+		"strip/2": image.Rect(6, 0, 9, 2),
+	}
+	if len(regions) != len(want) {
+		t.Errorf("got %d regions, want %d", len(regions), len(want))
+	}
+	for name, r := range want {
+		if regions[name].Rect != r {
+			t.Errorf("region of %s = %v, want %v", name, regions[name].Rect, r)
+		}
+	}
+}
+
+func TestRegionsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		sprite Sprite
+		err    string
+	}{
+		{"missing image", Sprite{Image: "c.png"}, "no image"},
+		{"outside", Sprite{Image: "a.png", X: 8, W: 4, H: 4}, "outside"},
+		{"more frames than pixels", Sprite{Image: "a.png", Frames: 11}, "don't fit"},
+	}
+	bounds := map[string]image.Rectangle{"a.png": image.Rect(0, 0, 10, 10)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Sheet{Sprites: map[string]Sprite{"s": tt.sprite}}
+			if _, err := s.Regions(bounds); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %v, want an error about %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"ghost/resource"
+	"ghost/sim"
+	"ghost/sprite"
+)
+
+// atlasWidth is how wide the texture the sprites are packed into is.
+const atlasWidth = 1024
+
+// animations lists the animations the game plays besides those of the
+// enemies.
+var animations = []string{
+	"player/idle", "player/left", "player/right", "player/fire",
+	"boss/idle", "boss/hit", "explosion",
+}
+
+// requiredAnimations returns every animation the game plays: an enemy is
+// drawn with the idle and hit animations of the sprite of its type.
+func requiredAnimations() []string {
+	names := append([]string(nil), animations...)
+	var enemies []string
+	for _, t := range sim.EnemyTypes {
+		enemies = append(enemies, t.Sprite+"/idle", t.Sprite+"/hit")
+	}
+	sort.Strings(enemies)
+	return append(names, enemies...)
+}
+
+// spriteSet holds the sprites of the sheet packed into one texture, so
+// drawing them doesn't switch between textures.
+type spriteSet struct {
+	sheet   *sprite.Sheet
+	atlas   *image.NRGBA             // The texture as decoded
+	images  map[string]*ebiten.Image // Parts of the texture by sprite name
+	regions map[string]image.Rectangle
+}
+
+// loadSprites packs the sprites of the sheet into an atlas.
+func loadSprites(assets *resource.Manager, sheet *sprite.Sheet) (*spriteSet, error) {
+	sources := map[string]image.Image{}
+	bounds := map[string]image.Rectangle{}
+	for _, p := range sheet.Images() {
+		src, err := assets.DecodeImage(p)
+		if err != nil {
+			return nil, err
+		}
+		sources[p], bounds[p] = src, src.Bounds()
+	}
+	regions, err := sheet.Regions(bounds)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sizes := make([]image.Point, len(names))
+	for i, name := range names {
+		sizes[i] = regions[name].Rect.Size()
+	}
+	placed, height, err := sprite.Pack(sizes, atlasWidth, 1)
+	if err != nil {
+		return nil, err
+	}
+	s := &spriteSet{
+		sheet:   sheet,
+		atlas:   image.NewNRGBA(image.Rect(0, 0, atlasWidth, height)),
+		images:  map[string]*ebiten.Image{},
+		regions: map[string]image.Rectangle{},
+	}
+	for i, name := range names {
+		r := regions[name]
+		draw.Draw(s.atlas, placed[i], sources[r.Image], r.Rect.Min, draw.Src)
+		s.regions[name] = placed[i]
+	}
+	texture := ebiten.NewImageFromImage(s.atlas)
+	for name, r := range s.regions {
+		s.images[name] = texture.SubImage(r).(*ebiten.Image)
+	}
+	return s, nil
+}
+
+// require reports the sprites of names the sheet lacks.
+func (s *spriteSet) require(names ...string) error {
+	var errs []error
+	for _, name := range names {
+		if _, ok := s.images[name]; !ok {
+			errs = append(errs, fmt.Errorf("no sprite %q", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// image returns the sprite with the given name.
+func (s *spriteSet) image(name string) *ebiten.Image {
+	img, ok := s.images[name]
+	if !ok {
+		panic("no sprite " + name)
+	}
+	return img
+}
+
+// source returns the decoded pixels of the sprite with the given name.
+func (s *spriteSet) source(name string) image.Image {
+	s.image(name)
+	return s.atlas.SubImage(s.regions[name])
+}
+
+// frameAt returns the frame of the named animation shown t seconds after it
+// started.
+func (s *spriteSet) frameAt(name string, t float64) sprite.Frame {
+	return s.sheet.Animations[name].FrameAt(t)
+}
+
+// drawFrame draws a frame of an animation with the top left corner of the
+// unscaled sprite at (x, y). The frame scales the sprite around its center
+// and adds its colors to cm.
+func (g *Game) drawFrame(screen *ebiten.Image, f sprite.Frame, x, y float64, cm ebiten.ColorM) {
+	img := g.sprites.image(f.Sprite)
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	sx, sy := f.Scale()
+	op := &ebiten.DrawImageOptions{ColorM: cm}
+	op.GeoM.Translate(-w/2, -h/2)
+	op.GeoM.Scale(sx, sy)
+	op.GeoM.Translate(x+w/2, y+h/2)
+	if f.Tint != nil {
+		op.ColorM.Scale(f.Tint[0], f.Tint[1], f.Tint[2], 1)
+	}
+	if flash := f.Flash; flash > 0 && !g.settings.ReduceFlashes {
+		op.ColorM.Scale(1-flash, 1-flash, 1-flash, 1)
+		op.ColorM.Translate(flash, flash, flash, 0)
+	}
+	screen.DrawImage(img, op)
+}
+
+// explosion is a death explosion playing where something was destroyed.
+type explosion struct {
+	anim *sprite.Animator
+	x, y float64 // Center
+}
+
+// resetAnimations clears the animations of the previous run.
+func (g *Game) resetAnimations() {
+	g.playerAnim.Restart("player/idle")
+	g.explosions = nil
+}
+
+// explode starts an explosion centered on (x, y).
+func (g *Game) explode(x, y float64) {
+	g.explosions = append(g.explosions, explosion{anim: sprite.NewAnimator(g.sprites.sheet, "explosion"), x: x, y: y})
+}
+
+// animate advances the animations of the gameplay by one frame. fired
+// reports whether the player fired during it.
+func (g *Game) animate(fired bool) {
+	dt := 1 / float64(ebiten.TPS())
+
+	in := g.simInput()
+	switch {
+	case fired:
+		g.playerAnim.Restart("player/fire")
+	case in.Left || in.MoveX < -stickDeadZone:
+		g.playerAnim.Play("player/left")
+	case in.Right || in.MoveX > stickDeadZone:
+		g.playerAnim.Play("player/right")
+	case g.playerAnim.Playing() != "player/fire" || g.playerAnim.Done():
+		g.playerAnim.Play("player/idle")
+	}
+	g.playerAnim.Update(dt)
+
+	alive := g.explosions[:0]
+	for _, e := range g.explosions {
+		e.anim.Update(dt)
+		if !e.anim.Done() {
+			alive = append(alive, e)
+		}
+	}
+	g.explosions = alive
+}
+
+// drawExplosions draws the death explosions that are playing.
+func (g *Game) drawExplosions(screen *ebiten.Image) {
+	for _, e := range g.explosions {
+		g.drawExplosion(screen, e.anim.Frame(), e.x, e.y)
+	}
+}
+
+// drawExplosion draws a frame of an explosion centered on (x, y).
+func (g *Game) drawExplosion(screen *ebiten.Image, f sprite.Frame, x, y float64) {
+	b := g.sprites.image(f.Sprite).Bounds()
+	g.drawFrame(screen, f, x-float64(b.Dx())/2, y-float64(b.Dy())/2, ebiten.ColorM{})
+}
+
+// enemyFrame returns the frame an enemy is drawn with: the idle animation
+// of the sprite of its type, or its hit animation while it flashes.
+func (g *Game) enemyFrame(e *sim.Enemy) sprite.Frame {
+	name := sim.EnemyTypes[e.Type].Sprite
+	if e.Flash > 0 {
+		return g.sprites.frameAt(name+"/hit", float64(sim.FlashDuration-e.Flash)/sim.TicksPerSecond)
+	}
+	return g.sprites.frameAt(name+"/idle", float64(e.Age)/sim.TicksPerSecond)
+}
+
+// bossFrame returns the frame the boss is drawn with.
+func (g *Game) bossFrame() sprite.Frame {
+	b := &g.world.Boss
+	if b.Flash > 0 {
+		return g.sprites.frameAt("boss/hit", float64(sim.FlashDuration-b.Flash)/sim.TicksPerSecond)
+	}
+	return g.sprites.frameAt("boss/idle", float64(g.world.Frame)/sim.TicksPerSecond)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/tinne26/mpegg"
+
+	"ghost/level"
+	"ghost/save"
+)
+
+const (
+	levelScreenDuration = 3 * 60
+	videoScreenDuration = 3 * 60
+)
+
+// currentLevel returns the definition of the Story level being played.
+func (g *Game) currentLevel() level.Level {
+	return g.chapters[g.storyChapter].Levels[g.storyLevel]
+}
+
+// nextLevel returns the position of the level following the current one,
+// or false if the current level is the last of the story.
+func (g *Game) nextLevel() (StoryChapter, StoryLevel, bool) {
+	chapter, lvl := g.storyChapter, g.storyLevel+1
+	if int(lvl) >= len(g.chapters[chapter].Levels) {
+		chapter, lvl = chapter+1, 0
+	}
+	if int(chapter) >= len(g.chapters) {
+		return 0, 0, false
+	}
+	return chapter, lvl, true
+}
+
+// startLevel sets the world up for the current Story level and queues its
+// intro video, level screen and loadout screen before the gameplay.
+func (g *Game) startLevel() {
+	lvl := g.currentLevel()
+	g.world.ResetLevel(lvl.Waves)
+	g.checkpoint()
+
+	scenes := []Scene{&gameplayScene{}, &loadoutScene{}, &levelIntroScene{}}
+	video := lvl.IntroVideo
+	if video == "" && g.storyLevel == 0 {
+		video = g.chapters[g.storyChapter].IntroVideo
+	}
+	if video != "" {
+		scenes = append(scenes, &cutsceneScene{path: video})
+	}
+	g.setScenes(scenes...)
+}
+
+// completeLevel shows the "level completed" screen, or finishes the game
+// after the last level.
+func (g *Game) completeLevel() {
+	g.finishLevel()
+	if _, _, ok := g.nextLevel(); !ok {
+		g.setScenes(&victoryScene{})
+		return
+	}
+	g.pushScene(&levelCompleteScene{})
+}
+
+// checkGoal completes the current level, or summons its boss, once the
+// goal score is reached.
+func (g *Game) checkGoal() {
+	lvl := g.currentLevel()
+	switch lvl.Check(g.world) {
+	case level.Completed:
+		g.completeLevel()
+	case level.BossSummoned:
+		// Quitting during the boss fight continues it from when the boss
+		// appeared
+		g.checkpoint()
+	}
+}
+
+// cutsceneScene plays a video.
+type cutsceneScene struct {
+	baseScene
+	path    string
+	file    fs.File
+	player  *mpegg.Player
+	counter int
+}
+
+func (s *cutsceneScene) Enter(g *Game) {
+	if err := s.open(g); err != nil {
+		// The level goes on without its video
+		log.Printf("playing %s: %v", s.path, err)
+		g.popScene()
+		return
+	}
+	s.player.Play()
+	s.counter = videoScreenDuration
+}
+
+// open gets the video at s.path ready to play.
+func (s *cutsceneScene) open(g *Game) error {
+	src, err := g.assets.Open(s.path)
+	if err != nil {
+		return err
+	}
+	video, ok := src.(io.ReadSeeker)
+	if !ok {
+		src.Close()
+		return errors.New("the video can't be seeked")
+	}
+	player, err := mpegg.NewPlayer(video)
+	if err != nil {
+		src.Close()
+		return err
+	}
+	s.file = src
+	s.player = player
+	return nil
+}
+
+func (s *cutsceneScene) Exit(g *Game) {
+	if s.player == nil {
+		return // The video couldn't be opened
+	}
+	s.player.Pause()
+	s.file.Close()
+}
+
+func (s *cutsceneScene) Update(g *Game) error {
+	// Countdown the video screen timer
+	s.counter--
+	if s.counter <= 0 {
+		g.popScene()
+	}
+	return nil
+}
+
+func (s *cutsceneScene) Draw(g *Game, screen *ebiten.Image) {
+	// Draw the video
+	mpegg.Draw(screen, s.player.CurrentFrame())
+}
+
+// levelIntroScene shows the name of the coming level.
+type levelIntroScene struct {
+	baseScene
+	counter int
+}
+
+func (s *levelIntroScene) Enter(g *Game) {
+	s.counter = levelScreenDuration
+}
+
+func (s *levelIntroScene) Update(g *Game) error {
+	// Countdown the level screen timer
+	s.counter--
+	if s.counter <= 0 {
+		g.popScene()
+	}
+	return nil
+}
+
+func (s *levelIntroScene) Draw(g *Game, screen *ebiten.Image) {
+	drawText(screen, g.levelName(g.position()), 0, 0)
+}
+
+// levelCompleteScene announces the next level and then starts it.
+type levelCompleteScene struct {
+	baseScene
+	counter int
+}
+
+func (s *levelCompleteScene) Enter(g *Game) {
+	s.counter = levelScreenDuration
+}
+
+func (s *levelCompleteScene) Update(g *Game) error {
+	// Countdown the level completed screen timer
+	s.counter--
+	if s.counter <= 0 {
+		g.storyChapter, g.storyLevel, _ = g.nextLevel()
+		g.startLevel()
+	}
+	return nil
+}
+
+func (s *levelCompleteScene) Draw(g *Game, screen *ebiten.Image) {
+	chapter, lvl, _ := g.nextLevel()
+	next := save.Position{Chapter: int(chapter), Level: int(lvl)}
+	text := g.lang.T("level.completed", "level", g.levelName(g.position()), "next", g.levelName(next))
+	drawText(screen, text, 0, 0)
+}
+
+// background returns the image at path, loading it on first use. An image
+// that can't be loaded is replaced by the Competition background.
+func (g *Game) background(path string) *ebiten.Image {
+	if img, ok := g.backgrounds[path]; ok {
+		return img
+	}
+	img := backgroundImage
+	if src, err := g.assets.DecodeImage(path); err != nil {
+		log.Printf("%v, using the default background", err)
+	} else {
+		img = ebiten.NewImageFromImage(src)
+	}
+	g.backgrounds[path] = img
+	return img
+}
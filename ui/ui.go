@@ -0,0 +1,210 @@
+// Package ui is a small widget toolkit for the menus and the HUD.
+//
+// Widgets are placed by an anchor on their parent and an offset from it,
+// laid out with Layout, then updated and drawn every frame with a Context.
+// They react to the mouse through the recorded input state and to the
+// keyboard or gamepad through focus: a Panel moves the focus between its
+// focusable children with up and down, and confirm activates the focused one.
+package ui
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+
+	"ghost/input"
+)
+
+// Anchor is the point of the parent a widget is placed relative to. The
+// same point of the widget is put there.
+type Anchor int
+
+const (
+	TopLeft Anchor = iota
+	Top
+	TopRight
+	Left
+	Center
+	Right
+	BottomLeft
+	Bottom
+	BottomRight
+)
+
+// point returns the anchor point of r.
+func (a Anchor) point(r image.Rectangle) image.Point {
+	x := [3]int{r.Min.X, (r.Min.X + r.Max.X) / 2, r.Max.X}[a%3]
+	y := [3]int{r.Min.Y, (r.Min.Y + r.Max.Y) / 2, r.Max.Y}[a/3]
+	return image.Pt(x, y)
+}
+
+// Theme colors
+var (
+	TextColor     color.Color = color.White
+	DisabledColor color.Color = color.NRGBA{0x80, 0x80, 0x80, 0xff}
+	FillColor     color.Color = color.NRGBA{0x20, 0x20, 0x30, 0xc0}
+	HoverColor    color.Color = color.NRGBA{0x40, 0x40, 0x60, 0xe0}
+	PressedColor  color.Color = color.NRGBA{0x60, 0x30, 0x30, 0xe0}
+	FocusColor    color.Color = color.NRGBA{0xff, 0xd0, 0x40, 0xff}
+	PanelColor    color.Color = color.NRGBA{0x00, 0x00, 0x00, 0xa0}
+)
+
+// Context is what widgets read during a frame.
+type Context struct {
+	Input *input.Tracker // Input of this and the previous frames
+	// Cursor is where the mouse points, which the input state only records
+	// while the button is held. It only affects hover highlights.
+	Cursor image.Point
+	Face   font.Face
+}
+
+// JustPressed reports whether button b went down this frame.
+func (c *Context) JustPressed(b input.Button) bool {
+	return c.Input.JustPressed(b)
+}
+
+// Repeated reports whether button b went down this frame or repeats because
+// it is held, for moving through menus.
+func (c *Context) Repeated(b input.Button) bool {
+	return c.Input.Repeated(b)
+}
+
+// clickPoint returns where the mouse button is held, or where it was
+// released this frame.
+func (c *Context) clickPoint() image.Point {
+	s := c.Input.Current
+	if c.Input.JustUnclicked() {
+		s = c.Input.Previous
+	}
+	return image.Pt(s.CursorX, s.CursorY)
+}
+
+// clickedIn reports whether the mouse button went down inside r this frame.
+func (c *Context) clickedIn(r image.Rectangle) bool {
+	return c.Input.JustClicked() && c.clickPoint().In(r)
+}
+
+// releasedIn reports whether the mouse button went up inside r this frame.
+func (c *Context) releasedIn(r image.Rectangle) bool {
+	return c.Input.JustUnclicked() && c.clickPoint().In(r)
+}
+
+// heldIn reports whether the mouse button is held inside r.
+func (c *Context) heldIn(r image.Rectangle) bool {
+	return c.Input.Current.Click && c.clickPoint().In(r)
+}
+
+// Widget is an element of the user interface.
+type Widget interface {
+	base() *Base
+	// Focusable reports whether the widget can take the keyboard focus.
+	Focusable() bool
+	Update(ctx *Context)
+	Draw(screen *ebiten.Image, ctx *Context)
+}
+
+// Base holds the placement and state every widget has.
+type Base struct {
+	Anchor   Anchor
+	X, Y     int // Offset from the anchor point
+	W, H     int // Size, 0 to fit the content
+	Disabled bool
+
+	Hovered bool // The cursor is over the widget
+	Pressed bool // The mouse button is held over the widget
+	Focused bool // The widget has the keyboard focus
+
+	rect  image.Rectangle
+	armed bool // The mouse button went down over the widget and is held
+}
+
+func (b *Base) base() *Base { return b }
+
+// Rect returns where the widget was laid out.
+func (b *Base) Rect() image.Rectangle { return b.rect }
+
+func (b *Base) Focusable() bool { return false }
+
+// updateMouse updates the hover and pressed states and reports whether the
+// widget was clicked this frame: the mouse button went down over it and was
+// released over it again.
+func (b *Base) updateMouse(ctx *Context) bool {
+	if ctx.clickedIn(b.rect) {
+		b.armed = true
+	}
+	clicked := b.armed && ctx.releasedIn(b.rect)
+	if !ctx.Input.Current.Click {
+		b.armed = false
+	}
+	b.Hovered = !b.Disabled && ctx.Cursor.In(b.rect)
+	b.Pressed = !b.Disabled && b.armed && ctx.heldIn(b.rect)
+	return !b.Disabled && clicked
+}
+
+// sizer is implemented by widgets whose size can follow their content.
+type sizer interface {
+	contentSize(face font.Face) image.Point
+}
+
+// measure returns the size w takes up, following its content where it has
+// no size of its own.
+func measure(w Widget, face font.Face) image.Point {
+	b := w.base()
+	size := image.Pt(b.W, b.H)
+	if s, ok := w.(sizer); ok && (size.X == 0 || size.Y == 0) {
+		content := s.contentSize(face)
+		if size.X == 0 {
+			size.X = content.X
+		}
+		if size.Y == 0 {
+			size.Y = content.Y
+		}
+	}
+	return size
+}
+
+// Layout places w and its children inside parent.
+func Layout(w Widget, parent image.Rectangle, face font.Face) {
+	b := w.base()
+	size := measure(w, face)
+	// Put the widget's anchor point on the parent's
+	at := b.Anchor.point(parent).Add(image.Pt(b.X, b.Y))
+	offset := b.Anchor.point(image.Rectangle{Max: size})
+	b.rect = image.Rectangle{Min: at.Sub(offset), Max: at.Sub(offset).Add(size)}
+
+	p, ok := w.(*Panel)
+	if !ok {
+		return
+	}
+	inner := b.rect.Inset(p.Padding)
+	y := inner.Min.Y
+	for _, c := range p.Children {
+		if child, ok := c.(*Panel); ok {
+			child.nested = true
+		}
+		if !p.Vertical {
+			Layout(c, inner, face)
+			continue
+		}
+		// Stack the children, each in a row of its own height
+		h := measure(c, face).Y
+		Layout(c, image.Rect(inner.Min.X, y, inner.Max.X, y+h), face)
+		y += h + p.Spacing
+	}
+}
+
+// textSize returns the size of s drawn in face.
+func textSize(face font.Face, s string) image.Point {
+	return text.BoundString(face, s).Size()
+}
+
+// drawText draws s centered in r.
+func drawText(screen *ebiten.Image, face font.Face, s string, r image.Rectangle, clr color.Color) {
+	bounds := text.BoundString(face, s)
+	x := r.Min.X + (r.Dx()-bounds.Dx())/2 - bounds.Min.X
+	y := r.Min.Y + (r.Dy()-bounds.Dy())/2 - bounds.Min.Y
+	text.Draw(screen, s, face, x, y, clr)
+}
@@ -0,0 +1,396 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font"
+
+	"ghost/input"
+)
+
+// padding is the space around the text of buttons, lists and toggles.
+const padding = 6
+
+func fillRect(screen *ebiten.Image, r image.Rectangle, clr color.Color) {
+	vector.DrawFilledRect(screen, float32(r.Min.X), float32(r.Min.Y), float32(r.Dx()), float32(r.Dy()), clr, false)
+}
+
+func strokeRect(screen *ebiten.Image, r image.Rectangle, clr color.Color) {
+	vector.StrokeRect(screen, float32(r.Min.X), float32(r.Min.Y), float32(r.Dx()), float32(r.Dy()), 2, clr, false)
+}
+
+// Label shows a line of text. Text may span several lines.
+type Label struct {
+	Base
+	Text  string
+	Color color.Color // TextColor when nil
+}
+
+func (l *Label) contentSize(face font.Face) image.Point {
+	return textSize(face, l.Text)
+}
+
+func (l *Label) Update(ctx *Context) {}
+
+func (l *Label) Draw(screen *ebiten.Image, ctx *Context) {
+	clr := l.Color
+	if clr == nil {
+		clr = TextColor
+	}
+	drawText(screen, ctx.Face, l.Text, l.rect, clr)
+}
+
+// Button runs OnClick when clicked, or when confirm is pressed while it has
+// the focus. It shows Image if set and Text otherwise.
+type Button struct {
+	Base
+	Text    string
+	Image   *ebiten.Image
+	OnClick func()
+}
+
+func (b *Button) Focusable() bool { return !b.Disabled && b.Image == nil }
+
+func (b *Button) contentSize(face font.Face) image.Point {
+	if b.Image != nil {
+		return b.Image.Bounds().Size()
+	}
+	return textSize(face, b.Text).Add(image.Pt(4*padding, 2*padding))
+}
+
+func (b *Button) Update(ctx *Context) {
+	clicked := b.updateMouse(ctx)
+	if (clicked || b.Focused && ctx.JustPressed(input.Confirm)) && b.OnClick != nil {
+		b.OnClick()
+	}
+}
+
+func (b *Button) Draw(screen *ebiten.Image, ctx *Context) {
+	if b.Image != nil {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(b.rect.Min.X), float64(b.rect.Min.Y))
+		if b.Pressed {
+			op.ColorM.Scale(1, 0, 0, 0.5)
+		} else if b.Hovered {
+			op.ColorM.Scale(1, 1, 0.6, 1)
+		}
+		screen.DrawImage(b.Image, op)
+		return
+	}
+	fill, clr := FillColor, TextColor
+	switch {
+	case b.Disabled:
+		clr = DisabledColor
+	case b.Pressed:
+		fill = PressedColor
+	case b.Hovered:
+		fill = HoverColor
+	}
+	fillRect(screen, b.rect, fill)
+	if b.Focused {
+		strokeRect(screen, b.rect, FocusColor)
+	}
+	drawText(screen, ctx.Face, b.Text, b.rect, clr)
+}
+
+// List lets the player pick one of its items with up and down while it has
+// the focus, or with the mouse. Confirm or a click runs OnSelect.
+type List struct {
+	Base
+	Items    []string
+	Selected int
+	OnSelect func(i int)
+}
+
+func (l *List) Focusable() bool { return !l.Disabled }
+
+func (l *List) rowHeight(face font.Face) int {
+	return face.Metrics().Height.Ceil() + padding
+}
+
+func (l *List) contentSize(face font.Face) image.Point {
+	w := 0
+	for _, item := range l.Items {
+		if s := textSize(face, item); s.X > w {
+			w = s.X
+		}
+	}
+	return image.Pt(w+4*padding, len(l.Items)*l.rowHeight(face))
+}
+
+// row returns the rectangle of item i.
+func (l *List) row(i int, face font.Face) image.Rectangle {
+	h := l.rowHeight(face)
+	return image.Rect(l.rect.Min.X, l.rect.Min.Y+i*h, l.rect.Max.X, l.rect.Min.Y+(i+1)*h)
+}
+
+func (l *List) Update(ctx *Context) {
+	if l.updateMouse(ctx) {
+		for i := range l.Items {
+			if ctx.releasedIn(l.row(i, ctx.Face)) {
+				l.Selected = i
+				if l.OnSelect != nil {
+					l.OnSelect(i)
+				}
+				return
+			}
+		}
+	}
+	if !l.Focused || len(l.Items) == 0 {
+		return
+	}
+	switch {
+	case ctx.Repeated(input.Up):
+		l.Selected = (l.Selected + len(l.Items) - 1) % len(l.Items)
+	case ctx.Repeated(input.Down):
+		l.Selected = (l.Selected + 1) % len(l.Items)
+	case ctx.JustPressed(input.Confirm):
+		if l.OnSelect != nil {
+			l.OnSelect(l.Selected)
+		}
+	}
+}
+
+func (l *List) Draw(screen *ebiten.Image, ctx *Context) {
+	for i, item := range l.Items {
+		r := l.row(i, ctx.Face)
+		if i == l.Selected {
+			fillRect(screen, r, HoverColor)
+			if l.Focused {
+				strokeRect(screen, r, FocusColor)
+			}
+		}
+		drawText(screen, ctx.Face, item, r, TextColor)
+	}
+}
+
+// Toggle switches On when clicked or activated with confirm and runs
+// OnChange.
+type Toggle struct {
+	Base
+	Text     string
+	On       bool
+	OnChange func(on bool)
+}
+
+func (t *Toggle) Focusable() bool { return !t.Disabled }
+
+func (t *Toggle) contentSize(face font.Face) image.Point {
+	return textSize(face, t.Text+" [x]").Add(image.Pt(4*padding, 2*padding))
+}
+
+func (t *Toggle) Update(ctx *Context) {
+	clicked := t.updateMouse(ctx)
+	if clicked || t.Focused && ctx.JustPressed(input.Confirm) {
+		t.On = !t.On
+		if t.OnChange != nil {
+			t.OnChange(t.On)
+		}
+	}
+}
+
+func (t *Toggle) Draw(screen *ebiten.Image, ctx *Context) {
+	fill := FillColor
+	if t.Hovered {
+		fill = HoverColor
+	}
+	fillRect(screen, t.rect, fill)
+	if t.Focused {
+		strokeRect(screen, t.rect, FocusColor)
+	}
+	box := "[ ]"
+	if t.On {
+		box = "[x]"
+	}
+	drawText(screen, ctx.Face, t.Text+" "+box, t.rect, TextColor)
+}
+
+// Slider picks a value between Min and Max in steps of Step, with left and
+// right while it has the focus or by clicking on the bar. It runs OnChange
+// when the value changes.
+type Slider struct {
+	Base
+	Text           string
+	Value          float64
+	Min, Max, Step float64
+	OnChange       func(v float64)
+}
+
+func (s *Slider) Focusable() bool { return !s.Disabled }
+
+func (s *Slider) contentSize(face font.Face) image.Point {
+	return image.Pt(200, textSize(face, s.Text).Y+3*padding+6)
+}
+
+func (s *Slider) set(v float64) {
+	if v < s.Min {
+		v = s.Min
+	}
+	if v > s.Max {
+		v = s.Max
+	}
+	if s.Step > 0 {
+		v = s.Min + float64(int((v-s.Min)/s.Step+0.5))*s.Step
+	}
+	if v != s.Value {
+		s.Value = v
+		if s.OnChange != nil {
+			s.OnChange(v)
+		}
+	}
+}
+
+// bar returns the rectangle of the slider's bar.
+func (s *Slider) bar() image.Rectangle {
+	return image.Rect(s.rect.Min.X+padding, s.rect.Max.Y-padding-6, s.rect.Max.X-padding, s.rect.Max.Y-padding)
+}
+
+func (s *Slider) Update(ctx *Context) {
+	s.updateMouse(ctx)
+	if s.Pressed && s.Max > s.Min {
+		bar := s.bar()
+		f := float64(ctx.Input.Current.CursorX-bar.Min.X) / float64(bar.Dx())
+		s.set(s.Min + f*(s.Max-s.Min))
+	}
+	if s.Focused {
+		if ctx.Repeated(input.Left) {
+			s.set(s.Value - s.Step)
+		} else if ctx.Repeated(input.Right) {
+			s.set(s.Value + s.Step)
+		}
+	}
+}
+
+func (s *Slider) Draw(screen *ebiten.Image, ctx *Context) {
+	fill := FillColor
+	if s.Hovered {
+		fill = HoverColor
+	}
+	fillRect(screen, s.rect, fill)
+	if s.Focused {
+		strokeRect(screen, s.rect, FocusColor)
+	}
+	label := image.Rect(s.rect.Min.X, s.rect.Min.Y+padding, s.rect.Max.X, s.bar().Min.Y-padding)
+	drawText(screen, ctx.Face, s.Text, label, TextColor)
+	bar := s.bar()
+	fillRect(screen, bar, DisabledColor)
+	if s.Max > s.Min {
+		filled := bar
+		filled.Max.X = bar.Min.X + int(float64(bar.Dx())*(s.Value-s.Min)/(s.Max-s.Min))
+		fillRect(screen, filled, FocusColor)
+	}
+}
+
+// Panel groups widgets laid out inside it, optionally on a background, and
+// moves the focus between its focusable children with up and down. A focused
+// list keeps up and down to itself.
+type Panel struct {
+	Base
+	Children   []Widget
+	Padding    int
+	Background color.Color // Nothing is drawn behind the children when nil
+	Focus      int         // Index of the focused child
+	// Vertical stacks the children from the top down, Spacing pixels
+	// apart, instead of placing each by its anchor on the whole panel.
+	Vertical bool
+	Spacing  int
+
+	nested bool // Inside another panel, which passes the focus on
+}
+
+func (p *Panel) contentSize(face font.Face) image.Point {
+	var size image.Point
+	for i, c := range p.Children {
+		cs := measure(c, face)
+		if p.Vertical {
+			if cs.X > size.X {
+				size.X = cs.X
+			}
+			size.Y += cs.Y
+			if i > 0 {
+				size.Y += p.Spacing
+			}
+		} else {
+			size = image.Rectangle{Max: size}.Union(image.Rectangle{Max: cs}).Max
+		}
+	}
+	return size.Add(image.Pt(2*p.Padding, 2*p.Padding))
+}
+
+// Add appends widgets to the panel and returns it.
+func (p *Panel) Add(children ...Widget) *Panel {
+	p.Children = append(p.Children, children...)
+	return p
+}
+
+func (p *Panel) Focusable() bool {
+	for _, c := range p.Children {
+		if c.Focusable() {
+			return true
+		}
+	}
+	return false
+}
+
+// moveFocus moves the focus to the next focusable child in direction dir.
+func (p *Panel) moveFocus(dir int) {
+	n := len(p.Children)
+	for i := 1; i <= n; i++ {
+		next := ((p.Focus+dir*i)%n + n) % n
+		if p.Children[next].Focusable() {
+			p.Focus = next
+			return
+		}
+	}
+}
+
+func (p *Panel) Update(ctx *Context) {
+	p.updateMouse(ctx)
+	if len(p.Children) == 0 {
+		return
+	}
+	if p.Focus >= len(p.Children) || !p.Children[p.Focus].Focusable() {
+		p.moveFocus(1)
+	}
+	focused := p.Focused || !p.nested
+	if focused && !p.keepsKeys(p.Children[p.Focus]) {
+		if ctx.Repeated(input.Up) {
+			p.moveFocus(-1)
+		} else if ctx.Repeated(input.Down) {
+			p.moveFocus(1)
+		}
+	}
+	for i, c := range p.Children {
+		b := c.base()
+		b.Focused = focused && i == p.Focus && c.Focusable()
+		c.Update(ctx)
+		// Clicking a widget moves the focus to it. Hovering doesn't: the
+		// cursor is only recorded while the button is held, so focus that
+		// followed it would play back differently.
+		if c.Focusable() && !b.Disabled && ctx.clickedIn(b.rect) {
+			p.Focus = i
+		}
+	}
+}
+
+// keepsKeys reports whether the focused child w handles up and down
+// itself.
+func (p *Panel) keepsKeys(w Widget) bool {
+	switch w.(type) {
+	case *List, *Panel:
+		return true
+	}
+	return false
+}
+
+func (p *Panel) Draw(screen *ebiten.Image, ctx *Context) {
+	if p.Background != nil {
+		fillRect(screen, p.rect, p.Background)
+	}
+	for _, c := range p.Children {
+		c.Draw(screen, ctx)
+	}
+}